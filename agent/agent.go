@@ -2,13 +2,18 @@
 package agent
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Sirupsen/logrus"
-	gocache "github.com/patrickmn/go-cache"
+	"github.com/julienschmidt/httprouter"
+	"github.com/rcrowley/go-metrics"
 	"github.com/satori/go.uuid"
 
 	resourced_config "github.com/resourced/resourced/config"
@@ -18,20 +23,41 @@ import (
 	"github.com/resourced/resourced/libtime"
 	"github.com/resourced/resourced/loggers"
 	"github.com/resourced/resourced/readers"
+	"github.com/resourced/resourced/storage"
 	"github.com/resourced/resourced/writers"
 )
 
 // New is the constructor for Agent struct.
 func New() (*Agent, error) {
+	return newAgent(false)
+}
+
+// NewInMemory is like New, but results are always kept in memory instead of GeneralConfig.DbPath.
+// It is used by one-off commands, so they never touch the storage of a running agent.
+func NewInMemory() (*Agent, error) {
+	return newAgent(true)
+}
+
+func newAgent(inMemory bool) (*Agent, error) {
 	agent := &Agent{}
 
 	agent.ID = uuid.NewV4().String()
+	agent.runningConfigs = make(map[string]runningConfig)
+	agent.inFlightConfigs = make(map[string]bool)
+	agent.runStats = make(map[string]*RunStat)
+	agent.streams = newStreamHub()
+	agent.MetricsRegistry = metrics.NewRegistry()
+	agent.listeners = make([]net.Listener, 0)
+	agent.ctx, agent.cancel = context.WithCancel(context.Background())
+	agent.streamsCtx, agent.closeStreams = context.WithCancel(agent.ctx)
 
 	err := agent.setConfigs()
 	if err != nil {
 		return nil, err
 	}
 
+	agent.runSlots = make(chan struct{}, agent.GeneralConfig.Runs.MaxConcurrency)
+
 	err = agent.setTags()
 	if err != nil {
 		return nil, err
@@ -42,9 +68,31 @@ func New() (*Agent, error) {
 		return nil, err
 	}
 
-	agent.ResultDB = gocache.New(time.Duration(agent.GeneralConfig.TTL)*time.Second, 10*time.Second)
+	if !inMemory {
+		agent.DbPath = agent.GeneralConfig.DbPath
+	}
+
+	agent.ResultDB, err = storage.New(agent.DbPath, time.Duration(agent.GeneralConfig.TTL)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	historyDuration, err := time.ParseDuration(agent.GeneralConfig.History.Duration)
+	if err != nil {
+		return nil, err
+	}
+	agent.HistoryDB = storage.NewHistory(agent.GeneralConfig.History.Count, historyDuration)
+
 	agent.GraphiteDB = libmap.NewTSafeNestedMapInterface(nil)
+	agent.GraphiteClientDB = libmap.NewTSafeMapString(nil)
 	agent.ExecutorCounterDB = libmap.NewTSafeMapCounter(nil)
+	agent.ExecutorStateDB = executors.NewStateDB()
+
+	err = agent.setSilences(inMemory)
+	if err != nil {
+		return nil, err
+	}
+
 	agent.TCPLogDB = libmap.NewTSafeMapStrings(map[string][]string{
 		"Loglines": make([]string, 0),
 	})
@@ -57,24 +105,78 @@ func New() (*Agent, error) {
 type Agent struct {
 	ID                string
 	Tags              map[string]string
-	AccessTokens      []string
+	AccessTokens      []AccessToken
 	Configs           *resourced_config.Configs
 	GeneralConfig     resourced_config.GeneralConfig
 	DbPath            string
-	ResultDB          *gocache.Cache
+	ResultDB          storage.IStorage
+	HistoryDB         *storage.History
 	GraphiteDB        *libmap.TSafeNestedMapInterface
+	GraphiteClientDB  *libmap.TSafeMapString
 	ExecutorCounterDB *libmap.TSafeMapCounter
+	ExecutorStateDB   *executors.StateDB
+	ExecutorSilenceDB *executors.SilenceDB
 	TCPLogDB          *libmap.TSafeMapStrings
+	MetricsRegistry   metrics.Registry
+
+	router         *httprouter.Router
+	runningConfigs map[string]runningConfig
+
+	// inFlightConfigs are configs being executed right now, keyed by config.Key().
+	inFlightConfigs map[string]bool
+
+	// runSlots bounds the number of runs of RunForever loops at the same time to Runs.MaxConcurrency.
+	runSlots chan struct{}
+
+	// runStats are keyed by config path.
+	runStats     map[string]*RunStat
+	runStatsLock sync.Mutex
+
+	// streams pushes stored records to stream clients.
+	streams *streamHub
+
+	reloadLock sync.Mutex
+	listeners  []net.Listener
+
+	// ctx is cancelled on Shutdown, every run loop derives its context from it.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// streamsCtx is cancelled by CloseStreams or Shutdown, /stream handlers return when it is done.
+	streamsCtx   context.Context
+	closeStreams context.CancelFunc
+
+	// wg tracks run loops so Shutdown can wait for in-flight runs.
+	wg sync.WaitGroup
+
+	sync.RWMutex
+}
+
+// runningConfig remembers a config executed by RunForever and how to stop it.
+type runningConfig struct {
+	config resourced_config.Config
+	cancel context.CancelFunc
 }
 
 // Run executes a reader/writer/executor/log config.
-func (a *Agent) Run(config resourced_config.Config) (output []byte, err error) {
-	if config.GoStruct != "" && config.Kind == "reader" {
-		output, err = a.runGoStructReader(config)
-	} else if config.GoStruct != "" && config.Kind == "writer" {
-		output, err = a.runGoStructWriter(config)
-	} else if config.GoStruct != "" && config.Kind == "executor" {
-		output, err = a.runGoStructExecutor(config)
+// It stops waiting after the Timeout of config and kills the commands the run started, a timeout error is saved instead of its output.
+// GoStructs that do not run commands go on in the background until they end.
+func (a *Agent) Run(config resourced_config.Config) ([]byte, error) {
+	return a.runWithTimeout(config, nil)
+}
+
+// runWithTimeout executes config like Run. finished, when not nil, is called once the run really ends, even after a timeout.
+func (a *Agent) runWithTimeout(config resourced_config.Config, finished func()) (output []byte, err error) {
+	startedAt := time.Now()
+
+	timeout, err := a.runTimeout(config)
+	if err != nil {
+		err = fmt.Errorf("Timeout is invalid. Error: %v", err)
+		if finished != nil {
+			finished()
+		}
+	} else {
+		output, err = a.runGoStructUntil(config, timeout, finished)
 	}
 
 	if err != nil {
@@ -88,11 +190,129 @@ func (a *Agent) Run(config resourced_config.Config) (output []byte, err error) {
 		}).Error("Failed to execute runGoStructReader/runGoStructWriter/runGoStructExecutor")
 	}
 
+	if config.Kind == "reader" || config.Kind == "writer" || config.Kind == "executor" {
+		a.recordRunStat(config, startedAt, err)
+	}
+
 	err = a.saveRun(config, output, err)
 
 	return output, err
 }
 
+// runGoStructUntil executes the GoStruct of config in the background and waits for it up to timeout.
+// Commands started by the GoStruct are killed on timeout, see contextSetter.
+func (a *Agent) runGoStructUntil(config resourced_config.Config, timeout time.Duration, finished func()) ([]byte, error) {
+	type runResult struct {
+		output []byte
+		err    error
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan runResult, 1)
+
+	go func() {
+		var result runResult
+
+		if config.GoStruct != "" && config.Kind == "reader" {
+			result.output, result.err = a.runGoStructReader(ctx, config)
+		} else if config.GoStruct != "" && config.Kind == "writer" {
+			result.output, result.err = a.runGoStructWriter(ctx, config)
+		} else if config.GoStruct != "" && config.Kind == "executor" {
+			result.output, result.err = a.runGoStructExecutor(ctx, config)
+		}
+
+		// The run has ended before its caller returns, so an immediate next run is not rejected.
+		if finished != nil {
+			finished()
+		}
+
+		done <- result
+	}()
+
+	var timedOut <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timedOut = timer.C
+	}
+
+	select {
+	case result := <-done:
+		return result.output, result.err
+	case <-timedOut:
+		return nil, &runTimeoutError{timeout: timeout}
+	}
+}
+
+// runTimeoutError is saved when a run exceeds its Timeout.
+type runTimeoutError struct {
+	timeout time.Duration
+}
+
+func (e *runTimeoutError) Error() string {
+	return fmt.Sprintf("Run timed out after %v.", e.timeout)
+}
+
+// runTimeout returns the Timeout of config, or Runs.Timeout of general.toml when it is empty. Zero means no timeout.
+func (a *Agent) runTimeout(config resourced_config.Config) (time.Duration, error) {
+	timeout := config.Timeout
+	if timeout == "" {
+		timeout = a.GeneralConfig.Runs.Timeout
+	}
+	if timeout == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(timeout)
+}
+
+// ErrAlreadyRunning is returned by RunExclusive when the same config is being executed.
+var ErrAlreadyRunning = errors.New("Config is already running.")
+
+// RunExclusive executes a config like Run, unless the same config is already being executed,
+// either by its loop or on demand. Such runs are counted as skipped.
+// A run that timed out is still being executed until it really ends.
+func (a *Agent) RunExclusive(config resourced_config.Config) ([]byte, error) {
+	return a.runExclusive(config, nil)
+}
+
+// runExclusive executes config like RunExclusive. finished, when not nil, is called once the run really ends,
+// even after a timeout, or right away when the run is skipped.
+func (a *Agent) runExclusive(config resourced_config.Config, finished func()) ([]byte, error) {
+	key := config.Key()
+
+	if !a.startInFlight(key) {
+		a.recordSkippedRuns(config, 1)
+		if finished != nil {
+			finished()
+		}
+		return nil, ErrAlreadyRunning
+	}
+
+	return a.runWithTimeout(config, func() {
+		a.Lock()
+		delete(a.inFlightConfigs, key)
+		a.Unlock()
+
+		if finished != nil {
+			finished()
+		}
+	})
+}
+
+// startInFlight marks the config with key as being executed. It returns false when it already is.
+func (a *Agent) startInFlight(key string) bool {
+	a.Lock()
+	defer a.Unlock()
+
+	if a.inFlightConfigs[key] {
+		return false
+	}
+	a.inFlightConfigs[key] = true
+	return true
+}
+
 // initGoStructReader initialize and return IReader.
 func (a *Agent) initGoStructReader(config resourced_config.Config) (readers.IReader, error) {
 	return readers.NewGoStructByConfig(config)
@@ -106,7 +326,7 @@ func (a *Agent) initGoStructWriter(config resourced_config.Config) (writers.IWri
 	}
 
 	// Set configs data.
-	writer.SetConfigs(a.Configs)
+	writer.SetConfigs(a.configs())
 
 	// Get readers data.
 	readersData := make(map[string][]byte)
@@ -117,6 +337,10 @@ func (a *Agent) initGoStructWriter(config resourced_config.Config) (writers.IWri
 			record := a.commonGraphiteData()
 			record["Data"] = a.GraphiteDB.All()
 
+			if clients := a.GraphiteClientDB.All(); len(clients) > 0 {
+				record["Clients"] = clients
+			}
+
 			readerJsonBytes, err := json.Marshal(record)
 			if err == nil {
 				readersData[readerPath] = readerJsonBytes
@@ -173,16 +397,12 @@ func (a *Agent) initGoStructExecutor(config resourced_config.Config) (executors.
 		return nil, err
 	}
 
-	goodItems := libmap.AllNonExpiredCache(a.ResultDB)
-	goodItemsInBytes := make(map[string][]byte)
-
-	for key, item := range goodItems {
-		goodItemsInBytes[key] = item.Object.([]byte)
-	}
-
-	executor.SetReadersDataInBytes(goodItemsInBytes)
+	executor.SetReadersDataInBytes(a.ResultDB.Items())
+	executor.SetHistory(a.HistoryDB)
 	executor.SetCounterDB(a.ExecutorCounterDB)
-	executor.SetTags(a.Tags)
+	executor.SetStateDB(a.ExecutorStateDB)
+	executor.SetSilenceDB(a.ExecutorSilenceDB)
+	executor.SetTags(a.tags())
 
 	// Check if ResourcedMasterURL is not defined
 	// If so, set GeneralConfig.ResourcedMaster.URL as default
@@ -213,25 +433,41 @@ func (a *Agent) runGoStruct(readerOrWriterOrExecutor readers.IReader) ([]byte, e
 	if err != nil {
 		errData := make(map[string]string)
 		errData["Error"] = err.Error()
-		return json.Marshal(errData)
+
+		errJson, _ := json.Marshal(errData)
+		return errJson, err
 	}
 
 	return readerOrWriterOrExecutor.ToJson()
 }
 
+// contextSetter is implemented by GoStructs running commands, so the commands are killed once ctx is done.
+type contextSetter interface {
+	SetContext(ctx context.Context)
+}
+
+// setContext hands ctx to readerOrWriterOrExecutor when it runs commands.
+func setContext(readerOrWriterOrExecutor interface{}, ctx context.Context) {
+	if setter, ok := readerOrWriterOrExecutor.(contextSetter); ok {
+		setter.SetContext(ctx)
+	}
+}
+
 // runGoStructReader executes IReader and returns the output.
-func (a *Agent) runGoStructReader(config resourced_config.Config) ([]byte, error) {
+func (a *Agent) runGoStructReader(ctx context.Context, config resourced_config.Config) ([]byte, error) {
 	// Initialize IReader
 	reader, err := a.initGoStructReader(config)
 	if err != nil {
 		return nil, err
 	}
 
+	setContext(reader, ctx)
+
 	return a.runGoStruct(reader)
 }
 
 // runGoStructWriter executes IWriter and returns error if exists.
-func (a *Agent) runGoStructWriter(config resourced_config.Config) ([]byte, error) {
+func (a *Agent) runGoStructWriter(ctx context.Context, config resourced_config.Config) ([]byte, error) {
 	var writer writers.IWriter
 	var err error
 
@@ -249,6 +485,8 @@ func (a *Agent) runGoStructWriter(config resourced_config.Config) ([]byte, error
 		}
 	}
 
+	setContext(writer, ctx)
+
 	err = writer.GenerateData()
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
@@ -267,7 +505,7 @@ func (a *Agent) runGoStructWriter(config resourced_config.Config) ([]byte, error
 }
 
 // runGoStructExecutor executes IExecutor and returns the output.
-func (a *Agent) runGoStructExecutor(config resourced_config.Config) ([]byte, error) {
+func (a *Agent) runGoStructExecutor(ctx context.Context, config resourced_config.Config) ([]byte, error) {
 	var executor executors.IExecutor
 	var err error
 
@@ -277,6 +515,8 @@ func (a *Agent) runGoStructExecutor(config resourced_config.Config) ([]byte, err
 		return nil, err
 	}
 
+	setContext(executor, ctx)
+
 	return a.runGoStruct(executor)
 }
 
@@ -301,7 +541,7 @@ func (a *Agent) hostData() (*host.Host, error) {
 		return nil, err
 	}
 
-	h.Tags = a.Tags
+	h.Tags = a.tags()
 
 	return h, nil
 }
@@ -320,9 +560,9 @@ func (a *Agent) saveRun(config resourced_config.Config, output []byte, err error
 
 	record := config.CommonJsonData()
 
-	host, err := a.hostData()
-	if err != nil {
-		return err
+	host, hostErr := a.hostData()
+	if hostErr != nil {
+		return hostErr
 	}
 	record["Host"] = host
 
@@ -345,70 +585,247 @@ func (a *Agent) saveRun(config resourced_config.Config, output []byte, err error
 		return err
 	}
 
-	a.ResultDB.Set(config.PathWithPrefix(), recordInJson, gocache.DefaultExpiration)
+	if config.Kind == "reader" {
+		a.HistoryDB.Add(config.PathWithPrefix(), record["UnixNano"].(int64), recordInJson)
+	}
+
+	err = a.ResultDB.Set(config.PathWithPrefix(), recordInJson)
+	if err != nil {
+		return err
+	}
+
+	a.streams.Publish(config.PathWithPrefix(), record["UnixNano"].(int64), recordInJson)
 
-	return err
+	return nil
 }
 
 // GetRunByPath returns JSON data stored in local storage given path string.
 func (a *Agent) GetRunByPath(path string) ([]byte, error) {
-	valueInterface, found := a.ResultDB.Get(path)
+	value, found := a.ResultDB.Get(path)
 	if found {
-		return valueInterface.([]byte), nil
+		return value, nil
 	}
 	return nil, nil
 }
 
-// RunForever executes Run() in an infinite loop with a sleep of config.Interval.
-func (a *Agent) RunForever(config resourced_config.Config) {
-	go func(config resourced_config.Config) {
+// GetRunsByPathInRange returns JSON data of past runs given path string, ordered from oldest to newest.
+// Zero from or to means unbounded. When limit is positive, only the newest limit runs are returned.
+func (a *Agent) GetRunsByPathInRange(path string, from, to time.Time, limit int) [][]byte {
+	samples := a.HistoryDB.Range(path, from, to, limit)
+
+	runs := make([][]byte, len(samples))
+	for i, sample := range samples {
+		runs[i] = sample.Value
+	}
+
+	return runs
+}
+
+// RunForever executes Run() in an infinite loop, at the times scheduled by config.Interval, Align, Jitter, and Splay.
+// Runs of every config share Runs.MaxConcurrency slots.
+// The loop stops when the config is stopped via StopRunning or replaced by another RunForever call.
+func (a *Agent) RunForever(config resourced_config.Config) error {
+	scheduler, err := config.Scheduler()
+	if err != nil {
+		return err
+	}
+
+	ctx := a.startRunning(config)
+
+	a.wg.Add(1)
+	go func(ctx context.Context, config resourced_config.Config, scheduler *libtime.Scheduler) {
+		defer a.wg.Done()
+
 		for {
-			a.Run(config)
-			libtime.SleepString(config.Interval)
+			skipped := scheduler.Skipped
+
+			if scheduler.Wait(ctx) != nil {
+				return
+			}
+
+			// Run times passed while the previous run was going on, or waiting for a run slot.
+			if scheduler.Skipped > skipped {
+				a.recordSkippedRuns(config, scheduler.Skipped-skipped)
+			}
+
+			// Runs that timed out keep their slot until they really end.
+			if a.acquireRunSlot(ctx) != nil {
+				return
+			}
+			a.runExclusive(config, a.releaseRunSlot)
 		}
-	}(config)
+	}(ctx, config, scheduler)
+
+	return nil
 }
 
-// RunAllForever runs everything in an infinite loop.
-func (a *Agent) RunAllForever() {
-	for _, config := range a.Configs.Readers {
-		a.RunForever(config)
-	}
-	for _, config := range a.Configs.Writers {
-		a.RunForever(config)
+// RunLoggerForever tails the logger file and sends its loglines to master in an infinite loop, at the times scheduled by config.Interval.
+func (a *Agent) RunLoggerForever(config resourced_config.Config) error {
+	logger, err := loggers.NewGoStructByConfig(config)
+	if err != nil {
+		return err
 	}
-	for _, config := range a.Configs.Executors {
-		a.RunForever(config)
+
+	scheduler, err := config.Scheduler()
+	if err != nil {
+		return err
 	}
-	for _, config := range a.Configs.Loggers {
-		logger, err := loggers.NewGoStructByConfig(config)
-		if err != nil {
-			continue
-		}
 
-		go func() {
-			logger.RunBlocking()
-		}()
+	ctx := a.startRunning(config)
 
-		go func(config resourced_config.Config, logger loggers.ILogger) {
-			for {
-				loglines, err := a.SendLog(logger.GetData(), logger.GetFile())
-				if err != nil {
-					libtime.SleepString(config.Interval)
-					continue
-				}
+	go func() {
+		logger.RunBlocking()
+	}()
+
+	a.wg.Add(1)
+	go func(ctx context.Context, config resourced_config.Config, logger loggers.ILogger) {
+		defer a.wg.Done()
 
+		for {
+			if scheduler.Wait(ctx) != nil {
+				// Stop tailing and drain the remaining loglines before exiting.
+				logger.Stop()
+				a.SendLog(logger.GetData(), logger.GetFile())
+				return
+			}
+
+			loglines, err := a.SendLog(logger.GetData(), logger.GetFile())
+			if err == nil {
 				outputJson, err := json.Marshal(loglines)
-				if err != nil {
-					libtime.SleepString(config.Interval)
-					continue
+				if err == nil {
+					a.saveRun(config, outputJson, err)
+					a.PruneLogs(logger, logger.GetData())
 				}
-
-				a.saveRun(config, outputJson, err)
-				a.PruneLogs(logger, logger.GetData())
-				libtime.SleepString(config.Interval)
 			}
-		}(config, logger)
+		}
+	}(ctx, config, logger)
+
+	return nil
+}
+
+// runConfigForever dispatches config to RunForever or RunLoggerForever based on its kind.
+func (a *Agent) runConfigForever(config resourced_config.Config) error {
+	if config.Kind == "logger" {
+		return a.RunLoggerForever(config)
+	}
+
+	return a.RunForever(config)
+}
+
+// acquireRunSlot waits for one of the Runs.MaxConcurrency run slots, or until ctx is done.
+func (a *Agent) acquireRunSlot(ctx context.Context) error {
+	select {
+	case a.runSlots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// releaseRunSlot frees a run slot taken by acquireRunSlot.
+func (a *Agent) releaseRunSlot() {
+	<-a.runSlots
+}
+
+// startRunning registers config as running and returns the context that governs its loop.
+// If the same config key is already running, the previous loop is stopped first.
+func (a *Agent) startRunning(config resourced_config.Config) context.Context {
+	ctx, cancel := context.WithCancel(a.ctx)
+
+	a.Lock()
+	if previous, ok := a.runningConfigs[config.Key()]; ok {
+		previous.cancel()
+	}
+	a.runningConfigs[config.Key()] = runningConfig{config: config, cancel: cancel}
+	a.Unlock()
+
+	return ctx
+}
+
+// StopRunning stops the loop of a running config given its key.
+// The stat of the config is forgotten too, so a stopped config does not keep /health unhealthy.
+func (a *Agent) StopRunning(key string) {
+	a.Lock()
+	running, ok := a.runningConfigs[key]
+	if ok {
+		running.cancel()
+		delete(a.runningConfigs, key)
+	}
+	a.Unlock()
+
+	if ok {
+		a.deleteRunStat(running.config)
+	}
+}
+
+// RunningConfigs returns all configs that are currently executed in a loop, keyed by config.Key().
+func (a *Agent) RunningConfigs() map[string]resourced_config.Config {
+	a.RLock()
+	defer a.RUnlock()
+
+	configs := make(map[string]resourced_config.Config)
+	for key, running := range a.runningConfigs {
+		configs[key] = running.config
+	}
+	return configs
+}
+
+// RunAllForever runs everything in an infinite loop.
+func (a *Agent) RunAllForever() {
+	for _, config := range a.configs().All() {
+		err := a.runConfigForever(config)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"Error":           err.Error(),
+				"config.GoStruct": config.GoStruct,
+				"config.Path":     config.Path,
+				"config.Kind":     config.Kind,
+			}).Error("Failed to run config")
+		}
 	}
 	a.SendTCPLogForever(a.GeneralConfig.LogReceiver)
 }
+
+// Shutdown stops every reader/writer/executor/logger loop and closes the TCP listeners.
+// It waits for in-flight runs and pending log batches to finish until ctx is done.
+func (a *Agent) Shutdown(ctx context.Context) error {
+	a.cancel()
+
+	a.Lock()
+	for key, running := range a.runningConfigs {
+		running.cancel()
+		delete(a.runningConfigs, key)
+	}
+	listeners := a.listeners
+	a.listeners = make([]net.Listener, 0)
+	a.Unlock()
+
+	for _, listener := range listeners {
+		listener.Close()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return a.ResultDB.Close()
+	case <-ctx.Done():
+		a.ResultDB.Close()
+		return ctx.Err()
+	}
+}
+
+// CloseStreams ends every /stream response, so http.Server.Shutdown does not wait for them.
+// It is meant for http.Server.RegisterOnShutdown.
+func (a *Agent) CloseStreams() {
+	a.closeStreams()
+}
+
+// Stop is Shutdown without a deadline.
+func (a *Agent) Stop() error {
+	return a.Shutdown(context.Background())
+}
@@ -2,16 +2,174 @@ package agent
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/resourced/resourced/libstring"
 )
 
+// AccessTokenHashPrefix marks a token that is already hashed with SHA-256 in access-tokens files.
+const AccessTokenHashPrefix = "sha256:"
+
+// ScopeAdmin grants every permission.
+const ScopeAdmin = "admin"
+
+// ErrAccessTokenExpired is returned when a known access token is used after its expiry.
+var ErrAccessTokenExpired = errors.New("Access token has expired.")
+
+// ErrAdminScopeRequired is returned for the config API when no access tokens are configured.
+var ErrAdminScopeRequired = errors.New("The config API requires an access token with admin scope, none are configured.")
+
+// AccessToken is a hashed access token with its scopes.
+//
+// Scopes:
+//
+//	admin           Every request.
+//	read:<path>     GET and HEAD on path. A trailing * matches any suffix, e.g. read:/r/*
+//	write:<path>    POST, PUT, and DELETE on path, e.g. write:/x/silences
+//	read, write     Same as read:* and write:*
+type AccessToken struct {
+	// Hash is hex encoded SHA-256 of the token.
+	Hash   string
+	Scopes []string
+
+	// ExpiresAt is zero when the token never expires.
+	ExpiresAt time.Time
+}
+
+// HashAccessToken returns hex encoded SHA-256 of token, as stored in AccessToken.Hash.
+func HashAccessToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// Matches checks whether givenToken hashes to the token's hash, in constant time.
+func (token AccessToken) Matches(givenToken string) bool {
+	return subtle.ConstantTimeCompare([]byte(token.Hash), []byte(HashAccessToken(givenToken))) == 1
+}
+
+// IsExpired checks if the token has expired.
+func (token AccessToken) IsExpired() bool {
+	return !token.ExpiresAt.IsZero() && time.Now().After(token.ExpiresAt)
+}
+
+// requiredScope returns the scope needed to perform method on urlPath.
+// The config API under /api/ requires admin.
+func requiredScope(method, urlPath string) string {
+	if strings.HasPrefix(urlPath, "/api/") {
+		return ScopeAdmin
+	}
+
+	switch method {
+	case "GET", "HEAD", "OPTIONS":
+		return "read:" + urlPath
+	}
+	return "write:" + urlPath
+}
+
+// HasScope checks whether the token grants the required scope, e.g. read:/r/cpu/info.
+func (token AccessToken) HasScope(required string) bool {
+	requiredAction := strings.SplitN(required, ":", 2)[0]
+	requiredPath := strings.TrimPrefix(required, requiredAction+":")
+
+	for _, scope := range token.Scopes {
+		if scope == ScopeAdmin {
+			return true
+		}
+
+		action := strings.SplitN(scope, ":", 2)[0]
+		if action != requiredAction {
+			continue
+		}
+
+		pattern := strings.TrimPrefix(scope, action+":")
+		if pattern == action || pattern == "*" || pattern == requiredPath {
+			return true
+		}
+		if strings.HasSuffix(pattern, "*") && strings.HasPrefix(requiredPath, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseAccessTokenLine parses one line of an access-tokens file:
+//
+//	<token>[,<token>...] [<scope>,<scope>...] [expires=<RFC3339 or 2006-01-02>]
+//
+// Tokens must be hashed and prefixed with sha256:, plaintext tokens are rejected. Tokens without scopes are admin.
+func parseAccessTokenLine(line string) ([]AccessToken, error) {
+	line = strings.TrimSpace(line)
+
+	// Ensure that we ignore comments "//" or "#"
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+		return nil, nil
+	}
+
+	fields := strings.Fields(line)
+
+	scopes := []string{ScopeAdmin}
+	var expiresAt time.Time
+
+	for _, field := range fields[1:] {
+		if strings.HasPrefix(field, "expires=") {
+			expiresString := strings.TrimPrefix(field, "expires=")
+
+			var err error
+			expiresAt, err = time.Parse(time.RFC3339, expiresString)
+			if err != nil {
+				expiresAt, err = time.Parse("2006-01-02", expiresString)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("Invalid access token expiry: %v", expiresString)
+			}
+			continue
+		}
+
+		scopes = strings.Split(field, ",")
+	}
+
+	tokens := make([]AccessToken, 0)
+
+	for _, token := range strings.Split(fields[0], ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(token, AccessTokenHashPrefix) {
+			return nil, errors.New("Plaintext access token, store it as sha256:<output of echo -n token | sha256sum>")
+		}
+
+		hash := strings.ToLower(strings.TrimPrefix(token, AccessTokenHashPrefix))
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != sha256.Size*2 {
+			return nil, fmt.Errorf("Invalid SHA-256 access token hash: %v", token)
+		}
+
+		tokens = append(tokens, AccessToken{Hash: hash, Scopes: scopes, ExpiresAt: expiresAt})
+	}
+
+	return tokens, nil
+}
+
 func (a *Agent) setAccessTokens() error {
-	a.AccessTokens = make([]string, 0)
+	accessTokens := make([]AccessToken, 0)
+
+	defer func() {
+		a.Lock()
+		a.AccessTokens = accessTokens
+		a.Unlock()
+	}()
 
 	configDir := os.Getenv("RESOURCED_CONFIG_DIR")
 	if configDir == "" {
@@ -37,32 +195,68 @@ func (a *Agent) setAccessTokens() error {
 
 		scanner := bufio.NewScanner(file)
 		for scanner.Scan() {
-			tokensPerLine := strings.Split(scanner.Text(), ",")
-
-			// Ensure that we ignore comments "//" or "#"
-			for _, tokenPerLine := range tokensPerLine {
-				if !strings.HasPrefix(tokenPerLine, "#") && !strings.HasPrefix(tokenPerLine, "//") {
-					a.AccessTokens = append(a.AccessTokens, strings.TrimSpace(tokenPerLine))
-				}
+			tokens, err := parseAccessTokenLine(scanner.Text())
+			if err != nil {
+				return fmt.Errorf("%v in %v", err, fullpath)
 			}
+
+			accessTokens = append(accessTokens, tokens...)
 		}
 	}
 
 	return nil
 }
 
+// accessTokens returns the current access tokens, they are replaced on reload.
+func (a *Agent) accessTokens() []AccessToken {
+	a.RLock()
+	defer a.RUnlock()
+
+	return a.AccessTokens
+}
+
+// findAccessToken returns the access token matching givenToken.
+func (a *Agent) findAccessToken(givenToken string) (AccessToken, bool) {
+	for _, token := range a.accessTokens() {
+		if token.Matches(givenToken) {
+			return token, true
+		}
+	}
+
+	return AccessToken{}, false
+}
+
 // Check if a given access token is allowed.
 func (a *Agent) IsAllowed(givenToken string) bool {
 	// Allow all if there are no AccessTokens defined.
-	if len(a.AccessTokens) == 0 {
+	if len(a.accessTokens()) == 0 {
 		return true
 	}
 
-	for _, token := range a.AccessTokens {
-		if token == givenToken {
-			return true
-		}
+	token, found := a.findAccessToken(givenToken)
+
+	return found && !token.IsExpired()
+}
+
+// Authorize checks whether givenToken may perform method on urlPath.
+// It returns the HTTP status code to respond with, and an error explaining the refusal.
+func (a *Agent) Authorize(givenToken, method, urlPath string) (int, error) {
+	if len(a.accessTokens()) == 0 {
+		return http.StatusOK, nil
 	}
 
-	return false
+	token, found := a.findAccessToken(givenToken)
+	if !found {
+		return http.StatusUnauthorized, errors.New("You are not authorized to connect")
+	}
+	if token.IsExpired() {
+		return http.StatusUnauthorized, ErrAccessTokenExpired
+	}
+
+	scope := requiredScope(method, urlPath)
+	if !token.HasScope(scope) {
+		return http.StatusForbidden, fmt.Errorf("Access token is missing scope %v", scope)
+	}
+
+	return http.StatusOK, nil
 }
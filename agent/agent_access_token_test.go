@@ -21,3 +21,50 @@ func TestIsAllowed(t *testing.T) {
 		t.Errorf("IsAllowed is wrong. GivenToken: %v. AccessTokens: %v", givenToken, agent.AccessTokens)
 	}
 }
+
+func TestAuthorizeScopes(t *testing.T) {
+	agent := createAgentWithAccessTokensForTest(t)
+
+	expired, err := parseAccessTokenLine(AccessTokenHashPrefix + HashAccessToken("expired-token") + " admin expires=2000-01-01T00:00:00Z")
+	if err != nil {
+		t.Fatalf("Parsing access token line should work. Error: %v", err)
+	}
+	agent.AccessTokens = append(agent.AccessTokens, expired...)
+
+	for _, c := range []struct {
+		token      string
+		method     string
+		path       string
+		statusCode int
+	}{
+		{"2fd4e1c67a2d28fced849ee1bb76e7391b93eb12", "POST", "/x/silences", 200},
+		{"dashboard-token", "GET", "/r/cpu/info", 200},
+		{"dashboard-token", "GET", "/r", 200},
+		{"dashboard-token", "GET", "/logs/tcp", 403},
+		{"dashboard-token", "POST", "/r/cpu/info/run", 403},
+		{"2fd4e1c67a2d28fced849ee1bb76e7391b93eb12", "PUT", "/api/configs/readers/du", 200},
+		{"dashboard-token", "GET", "/api/configs", 403},
+		{"expired-token", "GET", "/r", 401},
+		{"unknown-token", "GET", "/r", 401},
+	} {
+		statusCode, err := agent.Authorize(c.token, c.method, c.path)
+		if statusCode != c.statusCode {
+			t.Errorf("Authorize is wrong. Token: %v, Method: %v, Path: %v, Status: %v, Error: %v", c.token, c.method, c.path, statusCode, err)
+		}
+	}
+
+	_, err = agent.Authorize("dashboard-token", "GET", "/logs/tcp")
+	if err == nil || err.Error() != "Access token is missing scope read:/logs/tcp" {
+		t.Errorf("Authorize should explain the missing scope. Error: %v", err)
+	}
+
+	if _, err := parseAccessTokenLine(AccessTokenHashPrefix + HashAccessToken("token") + " read expires=someday"); err == nil {
+		t.Errorf("Invalid expiry should be rejected.")
+	}
+
+	for _, line := range []string{"2fd4e1c67a2d28fced849ee1bb76e7391b93eb12", AccessTokenHashPrefix + HashAccessToken("token") + ",plaintext-token read", "sha256:not-a-hash"} {
+		if _, err := parseAccessTokenLine(line); err == nil {
+			t.Errorf("Plaintext tokens and invalid hashes should be rejected. Line: %v", line)
+		}
+	}
+}
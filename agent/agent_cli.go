@@ -0,0 +1,209 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	resourced_config "github.com/resourced/resourced/config"
+	"github.com/resourced/resourced/libstring"
+)
+
+// configSubdirs are the subdirectories of RESOURCED_CONFIG_DIR holding reader, writer, executor, and logger configs, in loading order.
+var configSubdirs = []string{"readers", "writers", "executors", "loggers"}
+
+// CheckConfigDir loads general.toml and every reader, writer, executor, and logger config under configDir.
+// Unlike New, it does not stop at the first problem: all of them are returned, prefixed by file path.
+// Nothing is run.
+func CheckConfigDir(configDir string) (*resourced_config.Configs, []error) {
+	configDir = libstring.ExpandTildeAndEnv(configDir)
+
+	problems := make([]error, 0)
+	report := func(fullpath string, err error) {
+		problems = append(problems, fmt.Errorf("%v: %v", fullpath, err))
+	}
+
+	generalConfigPath := filepath.Join(configDir, "general.toml")
+
+	generalConfig, err := resourced_config.NewGeneralConfig(configDir)
+	if err != nil {
+		report(generalConfigPath, err)
+	} else {
+		for _, err := range checkGeneralConfig(generalConfig) {
+			report(generalConfigPath, err)
+		}
+	}
+
+	a := &Agent{Configs: &resourced_config.Configs{}}
+
+	for _, subdir := range configSubdirs {
+		files, err := ioutil.ReadDir(filepath.Join(configDir, subdir))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			report(filepath.Join(configDir, subdir), err)
+			continue
+		}
+
+		for _, f := range files {
+			if f.IsDir() {
+				continue
+			}
+
+			fullpath := filepath.Join(configDir, subdir, f.Name())
+
+			config, err := resourced_config.NewConfig(fullpath, configKinds[subdir])
+			if err != nil {
+				report(fullpath, err)
+				continue
+			}
+
+			a.Configs = a.Configs.WithConfig(config)
+		}
+	}
+
+	for _, config := range a.Configs.All() {
+		err := a.ValidateConfig(config)
+		if err != nil {
+			report(config.FilePath, err)
+		}
+
+		if config.Kind == "writer" {
+			for _, err := range a.checkReaderPaths(config) {
+				report(config.FilePath, err)
+			}
+		}
+	}
+
+	return a.Configs, problems
+}
+
+// checkGeneralConfig checks the settings of general.toml that would stop the agent from starting.
+func checkGeneralConfig(generalConfig resourced_config.GeneralConfig) []error {
+	problems := make([]error, 0)
+
+	_, err := time.ParseDuration(generalConfig.History.Duration)
+	if err != nil {
+		problems = append(problems, fmt.Errorf("History.Duration is invalid. Error: %v", err))
+	}
+
+	_, err = time.ParseDuration(generalConfig.Runs.Timeout)
+	if err != nil {
+		problems = append(problems, fmt.Errorf("Runs.Timeout is invalid. Error: %v", err))
+	}
+
+	_, err = time.ParseDuration(generalConfig.Graphite.StatsInterval)
+	if err != nil {
+		problems = append(problems, fmt.Errorf("Graphite.StatsInterval is invalid. Error: %v", err))
+	}
+
+	allowedClientsWithoutCA := map[string]bool{
+		"HTTPS":       len(generalConfig.HTTPS.AllowedClients) > 0 && generalConfig.HTTPS.ClientCAFile == "",
+		"Graphite":    len(generalConfig.Graphite.AllowedClients) > 0 && generalConfig.Graphite.ClientCAFile == "",
+		"LogReceiver": len(generalConfig.LogReceiver.AllowedClients) > 0 && generalConfig.LogReceiver.ClientCAFile == "",
+	}
+	for _, section := range []string{"HTTPS", "Graphite", "LogReceiver"} {
+		if allowedClientsWithoutCA[section] {
+			problems = append(problems, fmt.Errorf("%v.AllowedClients requires %v.ClientCAFile.", section, section))
+		}
+	}
+
+	for _, reg := range generalConfig.Graphite.Blacklist {
+		_, err := regexp.Compile(reg)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("Graphite.Blacklist is invalid. Error: %v", err))
+		}
+	}
+
+	return problems
+}
+
+// checkReaderPaths checks that a writer lists ReaderPaths, and that each of them is the Path of a reader.
+func (a *Agent) checkReaderPaths(config resourced_config.Config) []error {
+	if len(config.ReaderPaths) == 0 {
+		return []error{errors.New("ReaderPaths is required.")}
+	}
+
+	problems := make([]error, 0)
+
+	for _, readerPath := range config.ReaderPaths {
+		if strings.HasSuffix(readerPath, "/graphite") {
+			continue
+		}
+		if len(a.readersByPath(readerPath)) == 0 {
+			problems = append(problems, fmt.Errorf("ReaderPaths: no reader has Path %v.", readerPath))
+		}
+	}
+
+	return problems
+}
+
+// readersByPath returns reader configs whose Path is readerPath, with or without /r prefix.
+func (a *Agent) readersByPath(readerPath string) []resourced_config.Config {
+	found := make([]resourced_config.Config, 0)
+
+	for _, reader := range a.Configs.Readers {
+		if reader.PathWithPrefix() == reader.PathWithKindPrefix("r", readerPath) {
+			found = append(found, reader)
+		}
+	}
+	return found
+}
+
+// ConfigFromFile loads a single config. Its kind is named after its directory, e.g. readers/uptime.toml is a reader.
+func ConfigFromFile(fullpath string) (resourced_config.Config, error) {
+	subdir := filepath.Base(filepath.Dir(fullpath))
+
+	kind, ok := configKinds[subdir]
+	if !ok {
+		return resourced_config.Config{}, fmt.Errorf("Config must be inside readers, writers, executors, or loggers directory. File: %v", fullpath)
+	}
+
+	return resourced_config.NewConfig(fullpath, kind)
+}
+
+// RunOnce executes a reader, writer, or executor config once and returns its output.
+// The readers a writer or executor depends on are run first, so it has data to work with.
+func (a *Agent) RunOnce(config resourced_config.Config) ([]byte, error) {
+	if config.Kind != "reader" && config.Kind != "writer" && config.Kind != "executor" {
+		return nil, fmt.Errorf("Only readers, writers, and executors can be run once. Kind: %v", config.Kind)
+	}
+
+	for _, reader := range a.readerDependencies(config) {
+		// Failures are logged by Run, the config still runs with the rest of the data.
+		a.Run(reader)
+	}
+
+	return a.Run(config)
+}
+
+// readerDependencies returns the readers listed in ReaderPaths of a writer, or referred to by Conditions of an executor.
+func (a *Agent) readerDependencies(config resourced_config.Config) []resourced_config.Config {
+	dependencies := make([]resourced_config.Config, 0)
+
+	switch config.Kind {
+	case "writer":
+		for _, readerPath := range config.ReaderPaths {
+			dependencies = append(dependencies, a.readersByPath(readerPath)...)
+		}
+
+	case "executor":
+		// Conditions may also be given as a GoStructField of the executor.
+		conditions, _ := config.GoStructFields["Conditions"].(string)
+		conditions = config.Conditions + " " + conditions
+
+		for _, reader := range a.Configs.Readers {
+			if strings.Contains(conditions, reader.PathWithPrefix()) {
+				dependencies = append(dependencies, reader)
+			}
+		}
+	}
+
+	return dependencies
+}
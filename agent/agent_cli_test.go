@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	_ "github.com/resourced/resourced/readers/haproxy"
+	_ "github.com/resourced/resourced/readers/mcrouter"
+	_ "github.com/resourced/resourced/readers/memcache"
+	_ "github.com/resourced/resourced/readers/mysql"
+	_ "github.com/resourced/resourced/readers/procfs"
+	_ "github.com/resourced/resourced/readers/redis"
+	_ "github.com/resourced/resourced/readers/varnish"
+)
+
+func TestCheckConfigDir(t *testing.T) {
+	configs, problems := CheckConfigDir("$GOPATH/src/github.com/resourced/resourced/tests/resourced-configs")
+	if len(problems) > 0 {
+		t.Errorf("Test configs should not have problems. Problems: %v", problems)
+	}
+	if len(configs.Readers) == 0 || len(configs.Writers) == 0 {
+		t.Errorf("Test configs should be loaded. Configs: %v", configs)
+	}
+
+	agent, configDir := createAgentWithConfigDirForTest(t)
+	defer os.RemoveAll(configDir)
+	defer agent.Stop()
+
+	os.MkdirAll(filepath.Join(configDir, "writers"), 0755)
+	os.MkdirAll(filepath.Join(configDir, "executors"), 0755)
+
+	files := map[string]string{
+		"readers/unknown.toml":      "GoStruct = \"NoSuchReader\"\nPath = \"/unknown\"\n",
+		"readers/interval.toml":     "GoStruct = \"Uptime\"\nPath = \"/uptime\"\nInterval = \"often\"\n",
+		"readers/broken.toml":       "GoStruct = \"Uptime\n",
+		"writers/no-readers.toml":   "GoStruct = \"StdOut\"\nPath = \"/stdout\"\n",
+		"writers/bad-readers.toml":  "GoStruct = \"StdOut\"\nPath = \"/stdout2\"\nReaderPaths = [\"/load-avg\", \"/r/missing\"]\n",
+		"executors/conditions.toml": "GoStruct = \"Shell\"\nPath = \"/shell\"\n\n[GoStructFields]\nCommand = \"uptime\"\nConditions = \"/r/load-avg.LoadAvg1m >\"\n",
+	}
+	for name, content := range files {
+		ioutil.WriteFile(filepath.Join(configDir, name), []byte(content), 0644)
+	}
+
+	_, problems = CheckConfigDir(configDir)
+
+	messages := make([]string, 0)
+	for _, problem := range problems {
+		messages = append(messages, strings.TrimPrefix(problem.Error(), configDir+"/"))
+	}
+	sort.Strings(messages)
+
+	expected := []string{
+		"executors/conditions.toml: Conditions are invalid.",
+		"readers/broken.toml: ",
+		"readers/interval.toml: Interval is invalid.",
+		"readers/unknown.toml: reader NoSuchReader: GoStruct is undefined.",
+		"writers/bad-readers.toml: ReaderPaths: no reader has Path /r/missing.",
+		"writers/no-readers.toml: ReaderPaths is required.",
+	}
+
+	if len(messages) != len(expected) {
+		t.Fatalf("Every problem should be reported. Problems: %v", messages)
+	}
+	for i, message := range messages {
+		if !strings.HasPrefix(message, expected[i]) {
+			t.Errorf("Problem should start with %q. Problem: %v", expected[i], message)
+		}
+	}
+}
+
+func TestReaderDependencies(t *testing.T) {
+	agent := createAgentForTest(t)
+
+	for _, config := range agent.Configs.Writers {
+		if config.GoStruct != "StdOut" {
+			continue
+		}
+
+		dependencies := agent.readerDependencies(config)
+		if len(dependencies) != len(config.ReaderPaths) {
+			t.Errorf("Every reader in ReaderPaths should be a dependency. Dependencies: %v", dependencies)
+		}
+	}
+
+	for _, config := range agent.Configs.Executors {
+		if config.GoStruct != "Shell" {
+			continue
+		}
+
+		dependencies := agent.readerDependencies(config)
+		if len(dependencies) != 1 || dependencies[0].Path != "/load-avg" {
+			t.Errorf("Reader in Conditions should be a dependency. Dependencies: %v", dependencies)
+		}
+	}
+
+	_, err := agent.RunOnce(agent.Configs.Loggers[0])
+	if err == nil {
+		t.Errorf("Loggers should not be run once.")
+	}
+}
@@ -3,10 +3,19 @@ package agent
 import (
 	"errors"
 	"os"
+	"path"
+	"reflect"
+
+	"github.com/Sirupsen/logrus"
 
 	resourced_config "github.com/resourced/resourced/config"
+	"github.com/resourced/resourced/libstring"
+	"github.com/resourced/resourced/writers"
 )
 
+// watchedConfigSubdirs are the subdirectories under RESOURCED_CONFIG_DIR that trigger a reload on changes.
+var watchedConfigSubdirs = []string{"readers", "writers", "executors", "loggers", "tags", "access-tokens"}
+
 // setConfigs reads config paths and setup configStorage.
 func (a *Agent) setConfigs() error {
 	configDir := os.Getenv("RESOURCED_CONFIG_DIR")
@@ -28,3 +37,160 @@ func (a *Agent) setConfigs() error {
 
 	return err
 }
+
+// configs returns the current configs. They are replaced, never modified, on reload and by the config API.
+func (a *Agent) configs() *resourced_config.Configs {
+	a.RLock()
+	defer a.RUnlock()
+
+	return a.Configs
+}
+
+// Reload re-reads tags and reader/writer/executor/logger configs from RESOURCED_CONFIG_DIR.
+// Loops of removed or changed configs are stopped, loops of new or changed configs are started,
+// and the HTTP router is rebuilt to match the new set of paths.
+func (a *Agent) Reload() error {
+	a.reloadLock.Lock()
+	defer a.reloadLock.Unlock()
+
+	configDir := os.Getenv("RESOURCED_CONFIG_DIR")
+	if configDir == "" {
+		return errors.New("RESOURCED_CONFIG_DIR is required")
+	}
+
+	err := a.setTags()
+	if err != nil {
+		return err
+	}
+
+	err = a.setAccessTokens()
+	if err != nil {
+		return err
+	}
+
+	newConfigs, err := resourced_config.NewConfigs(configDir)
+	if err != nil {
+		return err
+	}
+
+	a.Lock()
+	newConfigs = keepFailedConfigs(a.Configs, newConfigs)
+	a.Unlock()
+
+	toStop, toStart := diffConfigs(a.RunningConfigs(), newConfigs)
+
+	for _, key := range toStop {
+		a.StopRunning(key)
+	}
+
+	a.Lock()
+	a.Configs = newConfigs
+	a.Unlock()
+
+	for _, config := range toStart {
+		err := a.runConfigForever(config)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"Error":           err.Error(),
+				"config.GoStruct": config.GoStruct,
+				"config.Path":     config.Path,
+				"config.Kind":     config.Kind,
+			}).Error("Failed to run config after reload")
+		}
+	}
+
+	a.pruneRunStats()
+
+	a.setRouter(a.HttpRouter())
+
+	logrus.WithFields(logrus.Fields{
+		"Stopped": len(toStop),
+		"Started": len(toStart),
+	}).Info("Reloaded configs")
+
+	return nil
+}
+
+// keepFailedConfigs returns newConfigs with the previous version of configs that fail to load now,
+// so a broken edit does not stop a running config.
+func keepFailedConfigs(previous, newConfigs *resourced_config.Configs) *resourced_config.Configs {
+	if previous == nil {
+		return newConfigs
+	}
+
+	for _, config := range previous.All() {
+		if !libstring.StringInSlice(config.Key(), newConfigs.FailedFilePaths) {
+			continue
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"FilePath": config.FilePath,
+		}).Info("Keeping previous config, the changed file failed to load")
+
+		newConfigs = newConfigs.WithConfig(config)
+	}
+
+	return newConfigs
+}
+
+// diffConfigs compares running configs against newConfigs.
+// It returns keys of running configs that must be stopped and configs that must be started.
+func diffConfigs(running map[string]resourced_config.Config, newConfigs *resourced_config.Configs) ([]string, []resourced_config.Config) {
+	toStop := make([]string, 0)
+	toStart := make([]resourced_config.Config, 0)
+
+	wanted := make(map[string]resourced_config.Config)
+	for _, config := range newConfigs.All() {
+		wanted[config.Key()] = config
+	}
+
+	for key, runningConfig := range running {
+		newConfig, ok := wanted[key]
+		if !ok || !reflect.DeepEqual(newConfig, runningConfig) {
+			toStop = append(toStop, key)
+		}
+	}
+
+	for key, newConfig := range wanted {
+		runningConfig, ok := running[key]
+		if !ok || !reflect.DeepEqual(newConfig, runningConfig) {
+			toStart = append(toStart, newConfig)
+		}
+	}
+
+	return toStop, toStart
+}
+
+// reloadAndLogError reloads configs like Reload and logs the error, which is otherwise dropped by WatchDir.
+func (a *Agent) reloadAndLogError() error {
+	err := a.Reload()
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"Error": err.Error(),
+		}).Error("Failed to reload configs")
+	}
+	return err
+}
+
+// WatchConfigDirs watches readers, writers, executors, loggers, and tags directories and reloads on any changes.
+func (a *Agent) WatchConfigDirs() {
+	configDir := os.Getenv("RESOURCED_CONFIG_DIR")
+	if configDir == "" {
+		return
+	}
+	configDir = libstring.ExpandTildeAndEnv(configDir)
+
+	watcher := &writers.Base{}
+
+	for _, subdir := range watchedConfigSubdirs {
+		go func(fullpath string) {
+			err := watcher.WatchDir(fullpath, a.reloadAndLogError)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"Error": err.Error(),
+					"Path":  fullpath,
+				}).Debug("Unable to watch config directory")
+			}
+		}(path.Join(configDir, subdir))
+	}
+}
@@ -0,0 +1,424 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	resourced_config "github.com/resourced/resourced/config"
+	"github.com/resourced/resourced/executors"
+	"github.com/resourced/resourced/libhttp"
+	"github.com/resourced/resourced/libstring"
+	"github.com/resourced/resourced/loggers"
+	"github.com/resourced/resourced/queryparser"
+	"github.com/resourced/resourced/readers"
+	"github.com/resourced/resourced/writers"
+)
+
+// configKinds maps subdirectories of RESOURCED_CONFIG_DIR to config kinds.
+var configKinds = map[string]string{
+	"readers":   "reader",
+	"writers":   "writer",
+	"executors": "executor",
+	"loggers":   "logger",
+}
+
+// configNameRegexp restricts config names, so they cannot escape their subdirectory.
+var configNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// ErrConfigNotFound is returned when a config does not exist.
+var ErrConfigNotFound = errors.New("Config does not exist.")
+
+// configError is a config that fails validation.
+type configError struct {
+	error
+}
+
+// configPayload is a config as shown by the config API.
+type configPayload struct {
+	Name string
+	resourced_config.Config
+}
+
+// References are shown as written, instead of the secrets they resolve to.
+func newConfigPayload(config resourced_config.Config) configPayload {
+	return configPayload{Name: configName(config), Config: config.Uninterpolated()}
+}
+
+// configName returns the file name of config, without .toml extension.
+func configName(config resourced_config.Config) string {
+	return strings.TrimSuffix(filepath.Base(config.FilePath), ".toml")
+}
+
+// configFilePath returns the TOML file of a config given its subdirectory, e.g. readers, and name.
+func configFilePath(subdir, name string) (string, error) {
+	configDir := os.Getenv("RESOURCED_CONFIG_DIR")
+	if configDir == "" {
+		return "", errors.New("RESOURCED_CONFIG_DIR is required")
+	}
+
+	if _, ok := configKinds[subdir]; !ok {
+		return "", fmt.Errorf("Config kind must be readers, writers, executors, or loggers. Kind: %v", subdir)
+	}
+
+	name = strings.TrimSuffix(name, ".toml")
+	if !configNameRegexp.MatchString(name) {
+		return "", fmt.Errorf("Config name may only contain letters, digits, _, -, and . Name: %v", name)
+	}
+
+	return filepath.Join(libstring.ExpandTildeAndEnv(configDir), subdir, name+".toml"), nil
+}
+
+// findConfig returns the loaded config whose file is fullpath.
+func (a *Agent) findConfig(fullpath string) (resourced_config.Config, bool) {
+	a.RLock()
+	defer a.RUnlock()
+
+	for _, config := range a.Configs.All() {
+		if config.FilePath == fullpath {
+			return config, true
+		}
+	}
+	return resourced_config.Config{}, false
+}
+
+// ValidateConfig checks that config can be run: its GoStruct is registered and accepts GoStructFields,
+// its Interval, Jitter, Splay, and Timeout parse, its Conditions compile, and its Path is not taken by another config of the same kind.
+func (a *Agent) ValidateConfig(config resourced_config.Config) (err error) {
+	if config.GoStruct == "" {
+		return errors.New("GoStruct is required.")
+	}
+
+	_, err = config.Scheduler()
+	if err != nil {
+		return fmt.Errorf("Interval is invalid. Error: %v", err)
+	}
+
+	if config.Timeout != "" {
+		_, err = time.ParseDuration(config.Timeout)
+		if err != nil {
+			return fmt.Errorf("Timeout is invalid. Error: %v", err)
+		}
+	}
+
+	if config.Kind == "executor" {
+		// Conditions may also be given as a GoStructField of the executor.
+		fieldConditions, _ := config.GoStructFields["Conditions"].(string)
+
+		for _, conditions := range []string{config.Conditions, fieldConditions} {
+			if conditions == "" {
+				continue
+			}
+
+			_, err = queryparser.Compile(conditions)
+			if err != nil {
+				return fmt.Errorf("Conditions are invalid. Error: %v", err)
+			}
+		}
+	}
+
+	switch config.Kind {
+	case "reader":
+		_, err = readers.NewGoStructByConfig(config)
+	case "writer":
+		_, err = writers.NewGoStructByConfig(config)
+	case "executor":
+		_, err = executors.NewGoStructByConfig(config)
+	case "logger":
+		_, err = loggers.NewGoStructByConfig(config)
+	}
+	if err != nil {
+		return fmt.Errorf("%v %v: %v", config.Kind, config.GoStruct, err)
+	}
+
+	if config.Path != "" {
+		a.RLock()
+		defer a.RUnlock()
+
+		for _, other := range a.Configs.All() {
+			if other.Kind == config.Kind && other.Path == config.Path && other.Key() != config.Key() {
+				return fmt.Errorf("Path %v is already used by %v.", config.Path, other.FilePath)
+			}
+		}
+	}
+
+	return nil
+}
+
+// decodeConfigBody turns a TOML or JSON request body into TOML file content.
+func decodeConfigBody(r *http.Request) ([]byte, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return body, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var config resourced_config.Config
+
+	err = decoder.Decode(&config)
+	if err != nil {
+		return nil, fmt.Errorf("Config is not valid JSON. Error: %v", err)
+	}
+
+	for key, value := range config.GoStructFields {
+		config.GoStructFields[key] = tomlValue(value)
+	}
+
+	var buf bytes.Buffer
+
+	err = config.EncodeTOML(&buf)
+	return buf.Bytes(), err
+}
+
+// tomlValue converts JSON numbers to the types TOML decodes them into: int64 or float64.
+func tomlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+
+	case []interface{}:
+		for i, element := range v {
+			v[i] = tomlValue(element)
+		}
+
+	case map[string]interface{}:
+		for key, element := range v {
+			v[key] = tomlValue(element)
+		}
+	}
+	return value
+}
+
+// SaveConfig validates TOML content and writes it atomically to fullpath.
+// The loop and HTTP routes of the config are replaced without reloading other configs.
+func (a *Agent) SaveConfig(fullpath, kind string, content []byte) (resourced_config.Config, error) {
+	a.reloadLock.Lock()
+	defer a.reloadLock.Unlock()
+
+	// The temporary file lives outside of watched subdirectories, so it is never loaded by Reload.
+	configDir := filepath.Dir(filepath.Dir(fullpath))
+
+	tmpFile, err := ioutil.TempFile(configDir, "."+filepath.Base(fullpath))
+	if err != nil {
+		return resourced_config.Config{}, err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write(content)
+	tmpFile.Close()
+	if err != nil {
+		return resourced_config.Config{}, err
+	}
+
+	// Decode from disk, exactly like NewConfigs would.
+	config, err := resourced_config.NewConfig(tmpFile.Name(), kind)
+	if err != nil {
+		return config, &configError{err}
+	}
+	config.FilePath = fullpath
+
+	err = a.ValidateConfig(config)
+	if err != nil {
+		return config, &configError{err}
+	}
+
+	err = os.MkdirAll(filepath.Dir(fullpath), 0755)
+	if err != nil {
+		return config, err
+	}
+
+	err = os.Rename(tmpFile.Name(), fullpath)
+	if err != nil {
+		return config, err
+	}
+
+	a.StopRunning(config.Key())
+
+	a.Lock()
+	a.Configs = a.Configs.WithConfig(config)
+	a.Unlock()
+
+	err = a.runConfigForever(config)
+	if err != nil {
+		return config, err
+	}
+
+	a.setRouter(a.HttpRouter())
+
+	return config, nil
+}
+
+// DeleteConfig removes the config file at fullpath, stops its loop, and removes its HTTP routes.
+func (a *Agent) DeleteConfig(fullpath string) error {
+	a.reloadLock.Lock()
+	defer a.reloadLock.Unlock()
+
+	config, found := a.findConfig(fullpath)
+
+	err := os.Remove(fullpath)
+	if os.IsNotExist(err) && !found {
+		return ErrConfigNotFound
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if found {
+		a.StopRunning(config.Key())
+
+		a.Lock()
+		a.Configs = a.Configs.WithoutConfig(config.Key())
+		a.Unlock()
+
+		a.pruneRunStats()
+
+		a.setRouter(a.HttpRouter())
+	}
+
+	return nil
+}
+
+// ConfigsGetHandler returns function that shows all loaded configs, grouped by kind.
+func (a *Agent) ConfigsGetHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+
+		payload := map[string][]configPayload{
+			"Readers":   make([]configPayload, 0),
+			"Writers":   make([]configPayload, 0),
+			"Executors": make([]configPayload, 0),
+			"Loggers":   make([]configPayload, 0),
+		}
+
+		a.RLock()
+		for _, config := range a.Configs.All() {
+			kind := strings.Title(config.Kind) + "s"
+			payload[kind] = append(payload[kind], newConfigPayload(config))
+		}
+		a.RUnlock()
+
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			libhttp.HandleErrorJson(w, err)
+			return
+		}
+
+		w.WriteHeader(200)
+		w.Write(payloadBytes)
+	}
+}
+
+// ConfigGetHandler returns function that shows a loaded config by kind and name.
+func (a *Agent) ConfigGetHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		fullpath, err := configFilePath(ps.ByName("kind"), ps.ByName("name"))
+		if err != nil {
+			libhttp.HandleErrorJsonWithStatusCode(w, err, 400)
+			return
+		}
+
+		config, found := a.findConfig(fullpath)
+		if !found {
+			libhttp.HandleErrorJsonWithStatusCode(w, ErrConfigNotFound, 404)
+			return
+		}
+
+		payloadBytes, err := json.Marshal(newConfigPayload(config))
+		if err != nil {
+			libhttp.HandleErrorJson(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		w.Write(payloadBytes)
+	}
+}
+
+// ConfigPutHandler returns function that creates or replaces a config from a TOML or JSON body.
+func (a *Agent) ConfigPutHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		fullpath, err := configFilePath(ps.ByName("kind"), ps.ByName("name"))
+		if err != nil {
+			libhttp.HandleErrorJsonWithStatusCode(w, err, 400)
+			return
+		}
+
+		content, err := decodeConfigBody(r)
+		if err != nil {
+			libhttp.HandleErrorJsonWithStatusCode(w, err, 400)
+			return
+		}
+
+		_, existed := a.findConfig(fullpath)
+
+		config, err := a.SaveConfig(fullpath, configKinds[ps.ByName("kind")], content)
+		if _, ok := err.(*configError); ok {
+			libhttp.HandleErrorJsonWithStatusCode(w, err, 400)
+			return
+		}
+		if err != nil {
+			libhttp.HandleErrorJson(w, err)
+			return
+		}
+
+		payloadBytes, err := json.Marshal(newConfigPayload(config))
+		if err != nil {
+			libhttp.HandleErrorJson(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if existed {
+			w.WriteHeader(200)
+		} else {
+			w.WriteHeader(201)
+		}
+		w.Write(payloadBytes)
+	}
+}
+
+// ConfigDeleteHandler returns function that removes a config by kind and name.
+func (a *Agent) ConfigDeleteHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		fullpath, err := configFilePath(ps.ByName("kind"), ps.ByName("name"))
+		if err != nil {
+			libhttp.HandleErrorJsonWithStatusCode(w, err, 400)
+			return
+		}
+
+		err = a.DeleteConfig(fullpath)
+		if err == ErrConfigNotFound {
+			libhttp.HandleErrorJsonWithStatusCode(w, err, 404)
+			return
+		}
+		if err != nil {
+			libhttp.HandleErrorJson(w, err)
+			return
+		}
+
+		w.WriteHeader(204)
+	}
+}
@@ -0,0 +1,198 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// configAPITokenForTest is the admin access token of agents created by createAgentWithConfigDirForTest.
+const configAPITokenForTest = "config-api-token"
+
+func createAgentWithConfigDirForTest(t *testing.T) (*Agent, string) {
+	configDir, err := ioutil.TempDir("", "resourced-configs")
+	if err != nil {
+		t.Fatalf("Creating temp dir should work. Error: %v", err)
+	}
+
+	generalConfig, err := ioutil.ReadFile(os.ExpandEnv("$GOPATH/src/github.com/resourced/resourced/tests/resourced-configs/general.toml"))
+	if err != nil {
+		t.Fatalf("Reading general.toml should work. Error: %v", err)
+	}
+
+	os.MkdirAll(filepath.Join(configDir, "readers"), 0755)
+	os.MkdirAll(filepath.Join(configDir, "access-tokens"), 0755)
+	ioutil.WriteFile(filepath.Join(configDir, "general.toml"), generalConfig, 0644)
+	ioutil.WriteFile(filepath.Join(configDir, "access-tokens", "default"), []byte(AccessTokenHashPrefix+HashAccessToken(configAPITokenForTest)+"\n"), 0600)
+	ioutil.WriteFile(filepath.Join(configDir, "readers", "load-avg.toml"), []byte("GoStruct = \"LoadAvg\"\nPath = \"/load-avg\"\nInterval = \"1h\"\n"), 0644)
+
+	os.Setenv("RESOURCED_CONFIG_DIR", configDir)
+	os.Setenv("RESOURCED_STATE_DIR", configDir)
+
+	agent, err := New()
+	if err != nil {
+		t.Fatalf("Initializing agent should work. Error: %v", err)
+	}
+
+	return agent, configDir
+}
+
+func TestConfigAPI(t *testing.T) {
+	agent, configDir := createAgentWithConfigDirForTest(t)
+	defer os.RemoveAll(configDir)
+	defer agent.Stop()
+
+	request := func(method, url, contentType, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		req.SetBasicAuth(configAPITokenForTest, "")
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		resp := httptest.NewRecorder()
+		agent.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := request("PUT", "/api/configs/readers/du", "", "GoStruct = \"Du\"\nPath = \"/du\"\nInterval = \"1h\"\n\n[GoStructFields]\nFSPaths = \"/tmp\"\n")
+	if resp.Code != 201 {
+		t.Fatalf("Creating config should work. Status: %v, Body: %s", resp.Code, resp.Body.Bytes())
+	}
+
+	fullpath := filepath.Join(configDir, "readers", "du.toml")
+	if _, err := os.Stat(fullpath); err != nil {
+		t.Fatalf("Config file should be written. Error: %v", err)
+	}
+	if _, ok := agent.RunningConfigs()[fullpath]; !ok {
+		t.Fatalf("Config should be running after PUT.")
+	}
+	if resp = request("GET", "/r/du", "", ""); resp.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Config routes should be added after PUT. Status: %v", resp.Code)
+	}
+
+	resp = request("PUT", "/api/configs/readers/du", "application/json", `{"GoStruct": "Du", "Path": "/du", "Interval": "2h", "GoStructFields": {"FSPaths": "/var"}}`)
+	if resp.Code != 200 {
+		t.Fatalf("Replacing config with JSON should work. Status: %v, Body: %s", resp.Code, resp.Body.Bytes())
+	}
+	if agent.RunningConfigs()[fullpath].Interval != "2h" {
+		t.Fatalf("Running config should be replaced. Config: %v", agent.RunningConfigs()[fullpath])
+	}
+
+	resp = request("GET", "/api/configs", "", "")
+	var payload map[string][]configPayload
+	json.Unmarshal(resp.Body.Bytes(), &payload)
+	if len(payload["Readers"]) != 2 {
+		t.Fatalf("Listing configs should show every reader. Body: %s", resp.Body.Bytes())
+	}
+
+	invalid := map[string]string{
+		"/api/configs/readers/bad":      `GoStruct = "DoesNotExist"`,
+		"/api/configs/readers/typo":     "GoStruct = \"Du\"\nInterval = \"soon\"",
+		"/api/configs/readers/cron":     "GoStruct = \"Du\"\nInterval = \"*/5 * * *\"",
+		"/api/configs/readers/fields":   "GoStruct = \"Du\"\n[GoStructFields]\nFSPaths = 1",
+		"/api/configs/readers/taken":    "GoStruct = \"LoadAvg\"\nPath = \"/load-avg\"",
+		"/api/configs/readers/..":       `GoStruct = "Du"`,
+		"/api/configs/widgets/du":       `GoStruct = "Du"`,
+		"/api/configs/readers/not-toml": `GoStruct = `,
+	}
+	for url, body := range invalid {
+		resp = request("PUT", url, "", body)
+		if resp.Code != 400 {
+			t.Fatalf("Invalid config should be rejected. URL: %v, Status: %v, Body: %s", url, resp.Code, resp.Body.Bytes())
+		}
+	}
+
+	files, _ := ioutil.ReadDir(filepath.Join(configDir, "readers"))
+	if len(files) != 2 {
+		t.Fatalf("Invalid configs should not be written. Files: %v", len(files))
+	}
+
+	resp = request("DELETE", "/api/configs/readers/du", "", "")
+	if resp.Code != 204 {
+		t.Fatalf("Deleting config should work. Status: %v, Body: %s", resp.Code, resp.Body.Bytes())
+	}
+	if _, ok := agent.RunningConfigs()[fullpath]; ok {
+		t.Fatalf("Config should be stopped after DELETE.")
+	}
+	if resp = request("GET", "/api/configs/readers/du", "", ""); resp.Code != 404 {
+		t.Fatalf("Deleted config should not exist. Status: %v", resp.Code)
+	}
+	if resp = request("DELETE", "/api/configs/readers/du", "", ""); resp.Code != 404 {
+		t.Fatalf("Deleting missing config should get 404. Status: %v", resp.Code)
+	}
+}
+
+func TestConfigAPIWithoutAccessTokens(t *testing.T) {
+	agent, configDir := createAgentWithConfigDirForTest(t)
+	defer os.RemoveAll(configDir)
+	defer agent.Stop()
+
+	agent.AccessTokens = make([]AccessToken, 0)
+
+	for _, method := range []string{"GET", "PUT", "DELETE"} {
+		req, _ := http.NewRequest(method, "/api/configs/readers/shell", bytes.NewBufferString("GoStruct = \"Shell\"\nPath = \"/shell\"\n\n[GoStructFields]\nCommand = \"touch /tmp/owned\"\n"))
+		resp := httptest.NewRecorder()
+		agent.ServeHTTP(resp, req)
+
+		if resp.Code != 403 {
+			t.Errorf("Config API should be refused without access tokens. Method: %v, Status: %v, Body: %s", method, resp.Code, resp.Body.Bytes())
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(configDir, "readers", "shell.toml")); err == nil {
+		t.Errorf("Config should not be written without access tokens.")
+	}
+
+	req, _ := http.NewRequest("GET", "/r/load-avg", nil)
+	resp := httptest.NewRecorder()
+	agent.ServeHTTP(resp, req)
+
+	if resp.Code == 401 || resp.Code == 403 {
+		t.Errorf("Data endpoints should stay open without access tokens. Status: %v", resp.Code)
+	}
+}
+
+func TestConfigAPIRedactsSecrets(t *testing.T) {
+	agent, configDir := createAgentWithConfigDirForTest(t)
+	defer os.RemoveAll(configDir)
+	defer agent.Stop()
+
+	os.Setenv("RESOURCED_TEST_FSPATHS", configDir)
+	defer os.Unsetenv("RESOURCED_TEST_FSPATHS")
+
+	request := func(method, url, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		req.SetBasicAuth(configAPITokenForTest, "")
+		resp := httptest.NewRecorder()
+		agent.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := request("PUT", "/api/configs/readers/du", "GoStruct = \"Du\"\nPath = \"/du\"\nInterval = \"1h\"\n\n[GoStructFields]\nFSPaths = \"${env:RESOURCED_TEST_FSPATHS}\"\n")
+	if resp.Code != 201 {
+		t.Fatalf("Creating config with references should work. Status: %v, Body: %s", resp.Code, resp.Body.Bytes())
+	}
+
+	resp = request("GET", "/api/configs/readers/du", "")
+	if !strings.Contains(resp.Body.String(), "${env:RESOURCED_TEST_FSPATHS}") {
+		t.Errorf("Config API should show references as written. Body: %s", resp.Body.Bytes())
+	}
+
+	resp = request("POST", "/r/du/run", "")
+	if resp.Code != 200 {
+		t.Fatalf("Running config should work. Status: %v, Body: %s", resp.Code, resp.Body.Bytes())
+	}
+	if strings.Contains(resp.Body.String(), configDir) || !strings.Contains(resp.Body.String(), "[REDACTED]") {
+		t.Errorf("Resolved values should be redacted from responses. Body: %s", resp.Body.Bytes())
+	}
+
+	resp = request("PUT", "/api/configs/readers/unset", "GoStruct = \"Du\"\nPath = \"/unset\"\n\n[GoStructFields]\nFSPaths = \"${env:RESOURCED_TEST_UNSET}\"\n")
+	if resp.Code != 400 || !strings.Contains(resp.Body.String(), "${env:RESOURCED_TEST_UNSET} is not set") {
+		t.Errorf("Unresolved references should be rejected. Status: %v, Body: %s", resp.Code, resp.Body.Bytes())
+	}
+}
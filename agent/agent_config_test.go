@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	resourced_config "github.com/resourced/resourced/config"
+)
+
+func TestDiffConfigs(t *testing.T) {
+	unchanged := resourced_config.Config{GoStruct: "LoadAvg", Path: "/load-avg", Interval: "1s", Kind: "reader", FilePath: "/tmp/readers/load-avg.toml"}
+	changed := resourced_config.Config{GoStruct: "Uptime", Path: "/uptime", Interval: "1s", Kind: "reader", FilePath: "/tmp/readers/uptime.toml"}
+	removed := resourced_config.Config{GoStruct: "Free", Path: "/free", Interval: "1s", Kind: "reader", FilePath: "/tmp/readers/free.toml"}
+	added := resourced_config.Config{GoStruct: "Ps", Path: "/ps", Interval: "1s", Kind: "reader", FilePath: "/tmp/readers/ps.toml"}
+
+	running := map[string]resourced_config.Config{
+		unchanged.Key(): unchanged,
+		changed.Key():   changed,
+		removed.Key():   removed,
+	}
+
+	changedAgain := changed
+	changedAgain.Interval = "10s"
+
+	newConfigs := &resourced_config.Configs{}
+	newConfigs.Readers = []resourced_config.Config{unchanged, changedAgain, added}
+
+	toStop, toStart := diffConfigs(running, newConfigs)
+
+	if len(toStop) != 2 {
+		t.Fatalf("Changed and removed configs should be stopped. toStop: %v", toStop)
+	}
+	for _, key := range toStop {
+		if key != changed.Key() && key != removed.Key() {
+			t.Errorf("Config should not be stopped. Key: %v", key)
+		}
+	}
+
+	if len(toStart) != 2 {
+		t.Fatalf("Changed and added configs should be started. toStart: %v", toStart)
+	}
+	for _, config := range toStart {
+		if config.Key() != changed.Key() && config.Key() != added.Key() {
+			t.Errorf("Config should not be started. Key: %v", config.Key())
+		}
+		if config.Key() == changed.Key() && config.Interval != "10s" {
+			t.Errorf("Changed config should be started with the new values. Interval: %v", config.Interval)
+		}
+	}
+}
+
+func TestKeepFailedConfigs(t *testing.T) {
+	broken := resourced_config.Config{GoStruct: "LoadAvg", Path: "/load-avg", Interval: "1s", Kind: "reader", FilePath: "/tmp/readers/load-avg.toml"}
+	removed := resourced_config.Config{GoStruct: "Free", Path: "/free", Interval: "1s", Kind: "reader", FilePath: "/tmp/readers/free.toml"}
+
+	previous := &resourced_config.Configs{}
+	previous.Readers = []resourced_config.Config{broken, removed}
+
+	newConfigs := &resourced_config.Configs{}
+	newConfigs.FailedFilePaths = []string{broken.FilePath}
+
+	kept := keepFailedConfigs(previous, newConfigs)
+
+	if len(kept.Readers) != 1 || kept.Readers[0].Key() != broken.Key() {
+		t.Errorf("Previous version of configs failing to load should be kept, removed configs should not. Readers: %v", kept.Readers)
+	}
+}
+
+func TestRunForeverAndStopRunning(t *testing.T) {
+	agent := createAgentForTest(t)
+
+	config := agent.Configs.Readers[0]
+	config.Interval = "1h"
+
+	agent.RunForever(config)
+
+	if _, ok := agent.RunningConfigs()[config.Key()]; !ok {
+		t.Fatalf("Config should be running after RunForever. Key: %v", config.Key())
+	}
+
+	agent.StopRunning(config.Key())
+
+	if _, ok := agent.RunningConfigs()[config.Key()]; ok {
+		t.Fatalf("Config should not be running after StopRunning. Key: %v", config.Key())
+	}
+}
+
+func TestReloadWhileServing(t *testing.T) {
+	agent, configDir := createAgentWithConfigDirForTest(t)
+	defer os.RemoveAll(configDir)
+	defer agent.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 10; i++ {
+			err := agent.Reload()
+			if err != nil {
+				t.Errorf("Reload should work. Error: %v", err)
+				return
+			}
+		}
+	}()
+
+	for _, url := range []string{"/", "/paths", "/r", "/r/paths", "/metrics", "/r/load-avg"} {
+		req, _ := http.NewRequest("GET", url, nil)
+		req.SetBasicAuth(configAPITokenForTest, "")
+		agent.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	<-done
+}
@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"net/http"
+
+	resourced_config "github.com/resourced/resourced/config"
+	"github.com/resourced/resourced/libencoding"
+	"github.com/resourced/resourced/libhttp"
+)
+
+// writeData renders JSON data in the format picked by ?format= or the Accept header, see libencoding.
+// Secrets resolved from config references are redacted before encoding, see resourced_config.RedactJSON.
+func (a *Agent) writeData(w http.ResponseWriter, r *http.Request, jsonData []byte) {
+	w.Header().Add("Vary", "Accept")
+
+	encoder, err := libencoding.Negotiate(r.URL.Query().Get("format"), r.Header.Get("Accept"))
+	if err != nil {
+		libhttp.HandleErrorJsonWithStatusCode(w, err, http.StatusNotAcceptable)
+		return
+	}
+
+	jsonData, err = resourced_config.RedactJSON(jsonData)
+	if err != nil {
+		libhttp.HandleErrorJson(w, err)
+		return
+	}
+
+	if encoder.ContentType() == "application/json" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		w.Write(jsonData)
+		return
+	}
+
+	data, err := libencoding.Decode(jsonData)
+	if err != nil {
+		libhttp.HandleErrorJson(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", encoder.ContentType())
+	w.WriteHeader(200)
+	encoder.Encode(w, data)
+}
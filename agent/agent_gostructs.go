@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	resourced_config "github.com/resourced/resourced/config"
+	"github.com/resourced/resourced/executors"
+	"github.com/resourced/resourced/libhttp"
+	"github.com/resourced/resourced/loggers"
+	"github.com/resourced/resourced/readers"
+	"github.com/resourced/resourced/writers"
+)
+
+// goStructSchemas returns the JSON Schema of every registered GoStruct, keyed by name.
+func goStructSchemas(names []string, description func(string) string, newGoStruct func(string) (interface{}, error)) (map[string]*resourced_config.Schema, error) {
+	schemas := make(map[string]*resourced_config.Schema)
+
+	for _, name := range names {
+		goStruct, err := newGoStruct(name)
+		if err != nil {
+			return nil, err
+		}
+		schemas[name] = resourced_config.GoStructSchema(name, description(name), goStruct)
+	}
+
+	return schemas, nil
+}
+
+// GoStructsGetHandler returns function that describes every registered reader, writer, executor, and logger GoStruct.
+// GoStructFields of each are described as JSON Schema.
+func (a *Agent) GoStructsGetHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+
+		payload := make(map[string]map[string]*resourced_config.Schema)
+
+		var err error
+
+		payload["Readers"], err = goStructSchemas(readers.Names(), readers.Description, func(name string) (interface{}, error) { return readers.NewGoStruct(name) })
+		if err == nil {
+			payload["Writers"], err = goStructSchemas(writers.Names(), writers.Description, func(name string) (interface{}, error) { return writers.NewGoStruct(name) })
+		}
+		if err == nil {
+			payload["Executors"], err = goStructSchemas(executors.Names(), executors.Description, func(name string) (interface{}, error) { return executors.NewGoStruct(name) })
+		}
+		if err == nil {
+			payload["Loggers"], err = goStructSchemas(loggers.Names(), loggers.Description, func(name string) (interface{}, error) { return loggers.NewGoStruct(name) })
+		}
+		if err != nil {
+			libhttp.HandleErrorJson(w, err)
+			return
+		}
+
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			libhttp.HandleErrorJson(w, err)
+			return
+		}
+
+		w.WriteHeader(200)
+		w.Write(payloadBytes)
+	}
+}
@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGoStructsGetHandler(t *testing.T) {
+	agent := createAgentForTest(t)
+	router := agent.HttpRouter()
+
+	req, _ := http.NewRequest("GET", "/gostructs", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != 200 {
+		t.Fatalf("GET /gostructs should work. Status: %v, Body: %s", resp.Code, resp.Body.Bytes())
+	}
+
+	var payload map[string]map[string]map[string]interface{}
+	err := json.Unmarshal(resp.Body.Bytes(), &payload)
+	if err != nil {
+		t.Fatalf("GET /gostructs response should be JSON. Error: %v", err)
+	}
+
+	for _, kind := range []string{"Readers", "Writers", "Executors", "Loggers"} {
+		if len(payload[kind]) == 0 {
+			t.Errorf("%v should be described.", kind)
+		}
+	}
+
+	du := payload["Readers"]["Du"]
+	if du["description"] == "" || du["type"] != "object" {
+		t.Errorf("Du should be described. Schema: %v", du)
+	}
+	if _, ok := du["properties"].(map[string]interface{})["FSPaths"]; !ok {
+		t.Errorf("FSPaths field of Du should be described. Schema: %v", du)
+	}
+
+	insights := payload["Writers"]["NewrelicInsights"]
+	if required, _ := insights["required"].([]interface{}); len(required) != 1 || required[0] != "EventType" {
+		t.Errorf("EventType field of NewrelicInsights should be required. Schema: %v", insights)
+	}
+
+	shell := payload["Readers"]["Shell"]
+	for _, name := range []string{"Command", "Args", "Timeout", "Env", "User"} {
+		if _, ok := shell["properties"].(map[string]interface{})[name]; !ok {
+			t.Errorf("%v field of Shell should be described. Schema: %v", name, shell)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rcrowley/go-metrics"
+
+	resourced_config "github.com/resourced/resourced/config"
+	"github.com/resourced/resourced/libhttp"
+)
+
+// UnhealthyConsecutiveFailures is the number of consecutive failed runs after which a config is unhealthy.
+const UnhealthyConsecutiveFailures = 3
+
+// RunStat is the health of a reader/writer/executor config. Timestamps are in UnixNano.
+type RunStat struct {
+	Path                string
+	Kind                string
+	LastRunAt           int64
+	LastSuccessAt       int64  `json:",omitempty"`
+	LastErrorAt         int64  `json:",omitempty"`
+	LastError           string `json:",omitempty"`
+	ConsecutiveFailures int64
+	RunCount            int64
+	FailureCount        int64
+
+	// TimeoutCount counts failed runs that exceeded their Timeout.
+	TimeoutCount int64
+
+	// SkippedCount counts run times that passed while the previous run was still going on.
+	SkippedCount  int64
+	LastSkippedAt int64 `json:",omitempty"`
+
+	// Duration percentiles of runs, in milliseconds.
+	Duration map[string]float64
+
+	timer               metrics.Timer
+	failures            metrics.Counter
+	timeouts            metrics.Counter
+	skipped             metrics.Counter
+	consecutiveFailures metrics.Gauge
+}
+
+// Healthy checks if the config has not failed UnhealthyConsecutiveFailures times in a row.
+func (stat RunStat) Healthy() bool {
+	return stat.ConsecutiveFailures < UnhealthyConsecutiveFailures
+}
+
+// metricsName converts path to go-metrics name, e.g. /r/cpu/info and Duration become Configs.r.cpu.info.Duration.
+func metricsName(path, name string) string {
+	return "Configs" + strings.Replace(path, "/", ".", -1) + "." + name
+}
+
+// recordRunStat updates the health of config after a run that started at startedAt.
+func (a *Agent) recordRunStat(config resourced_config.Config, startedAt time.Time, err error) {
+	if config.Path == "" {
+		return
+	}
+
+	now := time.Now()
+
+	a.runStatsLock.Lock()
+	defer a.runStatsLock.Unlock()
+
+	stat := a.runStat(config)
+
+	stat.timer.UpdateSince(startedAt)
+	stat.LastRunAt = now.UnixNano()
+	stat.RunCount++
+
+	if err != nil {
+		stat.LastErrorAt = now.UnixNano()
+		stat.LastError = err.Error()
+		stat.ConsecutiveFailures++
+		stat.FailureCount++
+		stat.failures.Inc(1)
+
+		if _, ok := err.(*runTimeoutError); ok {
+			stat.TimeoutCount++
+			stat.timeouts.Inc(1)
+		}
+	} else {
+		stat.LastSuccessAt = now.UnixNano()
+		stat.ConsecutiveFailures = 0
+	}
+
+	stat.consecutiveFailures.Update(stat.ConsecutiveFailures)
+}
+
+// recordSkippedRuns adds count runs of config that did not happen because the previous run was still going on.
+func (a *Agent) recordSkippedRuns(config resourced_config.Config, count int64) {
+	if config.Path == "" {
+		return
+	}
+
+	a.runStatsLock.Lock()
+	defer a.runStatsLock.Unlock()
+
+	stat := a.runStat(config)
+	stat.SkippedCount += count
+	stat.LastSkippedAt = time.Now().UnixNano()
+	stat.skipped.Inc(count)
+}
+
+// runStat returns the stat of config, creating it on first use. runStatsLock must be held.
+func (a *Agent) runStat(config resourced_config.Config) *RunStat {
+	path := config.PathWithPrefix()
+
+	stat, ok := a.runStats[path]
+	if !ok {
+		stat = &RunStat{Path: path, Kind: config.Kind}
+		stat.timer = metrics.GetOrRegisterTimer(metricsName(path, "Duration"), a.MetricsRegistry)
+		stat.failures = metrics.GetOrRegisterCounter(metricsName(path, "Failures"), a.MetricsRegistry)
+		stat.timeouts = metrics.GetOrRegisterCounter(metricsName(path, "Timeouts"), a.MetricsRegistry)
+		stat.skipped = metrics.GetOrRegisterCounter(metricsName(path, "Skipped"), a.MetricsRegistry)
+		stat.consecutiveFailures = metrics.GetOrRegisterGauge(metricsName(path, "ConsecutiveFailures"), a.MetricsRegistry)
+
+		a.runStats[path] = stat
+	}
+	return stat
+}
+
+// deleteRunStat forgets the stat of config and its metrics, e.g. when config is stopped.
+func (a *Agent) deleteRunStat(config resourced_config.Config) {
+	a.runStatsLock.Lock()
+	defer a.runStatsLock.Unlock()
+
+	a.deleteRunStatByPath(config.PathWithPrefix())
+}
+
+// pruneRunStats forgets the stats of paths that no config has anymore, e.g. after a reload.
+func (a *Agent) pruneRunStats() {
+	a.RLock()
+	paths := make(map[string]bool)
+	for _, config := range a.Configs.All() {
+		paths[config.PathWithPrefix()] = true
+	}
+	a.RUnlock()
+
+	a.runStatsLock.Lock()
+	defer a.runStatsLock.Unlock()
+
+	for path := range a.runStats {
+		if !paths[path] {
+			a.deleteRunStatByPath(path)
+		}
+	}
+}
+
+// deleteRunStatByPath removes the stat of path and unregisters its metrics. runStatsLock must be held.
+func (a *Agent) deleteRunStatByPath(path string) {
+	delete(a.runStats, path)
+
+	for _, name := range []string{"Duration", "Failures", "Timeouts", "Skipped", "ConsecutiveFailures"} {
+		a.MetricsRegistry.Unregister(metricsName(path, name))
+	}
+}
+
+// RunStats returns the health of every config that has run, keyed by path.
+func (a *Agent) RunStats() map[string]RunStat {
+	a.runStatsLock.Lock()
+	defer a.runStatsLock.Unlock()
+
+	stats := make(map[string]RunStat)
+
+	for path, stat := range a.runStats {
+		copied := *stat
+
+		percentiles := stat.timer.Percentiles([]float64{0.5, 0.9, 0.99})
+		copied.Duration = map[string]float64{
+			"P50": percentiles[0] / float64(time.Millisecond),
+			"P90": percentiles[1] / float64(time.Millisecond),
+			"P99": percentiles[2] / float64(time.Millisecond),
+			"Max": float64(stat.timer.Max()) / float64(time.Millisecond),
+		}
+
+		stats[path] = copied
+	}
+
+	return stats
+}
+
+// StatusGetHandler returns function that shows run statistics of all configs.
+func (a *Agent) StatusGetHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+
+		payloadBytes, err := json.Marshal(a.RunStats())
+		if err == nil {
+			payloadBytes, err = resourced_config.RedactJSON(payloadBytes)
+		}
+		if err != nil {
+			libhttp.HandleErrorJson(w, err)
+			return
+		}
+
+		w.WriteHeader(200)
+		w.Write(payloadBytes)
+	}
+}
+
+// HealthGetHandler returns function that responds with 503 when any config is unhealthy.
+func (a *Agent) HealthGetHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+
+		failing := make([]string, 0)
+		for path, stat := range a.RunStats() {
+			if !stat.Healthy() {
+				failing = append(failing, path)
+			}
+		}
+		sort.Strings(failing)
+
+		payload := map[string]interface{}{"Status": "OK", "Failing": failing}
+		statusCode := 200
+
+		if len(failing) > 0 {
+			payload["Status"] = "FAILING"
+			statusCode = 503
+		}
+
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			libhttp.HandleErrorJson(w, err)
+			return
+		}
+
+		w.WriteHeader(statusCode)
+		w.Write(payloadBytes)
+	}
+}
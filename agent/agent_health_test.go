@@ -0,0 +1,163 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+
+	resourced_config "github.com/resourced/resourced/config"
+)
+
+func TestHealthAndStatus(t *testing.T) {
+	agent := createAgentForTest(t)
+	router := agent.HttpRouter()
+
+	config := agent.Configs.Readers[0]
+	path := config.PathWithPrefix()
+
+	_, err := agent.Run(config)
+	if err != nil {
+		t.Fatalf("Run should work. Error: %v", err)
+	}
+
+	get := func(url string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", url, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	if resp := get("/health"); resp.Code != 200 {
+		t.Fatalf("Agent should be healthy. Status: %v, Body: %s", resp.Code, resp.Body.Bytes())
+	}
+
+	for i := 0; i < UnhealthyConsecutiveFailures; i++ {
+		agent.recordRunStat(config, time.Now(), errors.New("Reader is broken."))
+	}
+
+	resp := get("/health")
+	if resp.Code != 503 {
+		t.Fatalf("Agent should be unhealthy. Status: %v, Body: %s", resp.Code, resp.Body.Bytes())
+	}
+
+	resp = get("/status")
+
+	var stats map[string]RunStat
+	err = json.Unmarshal(resp.Body.Bytes(), &stats)
+	if err != nil {
+		t.Fatalf("Status response should be JSON. Error: %v", err)
+	}
+
+	stat := stats[path]
+	if stat.RunCount != 4 || stat.FailureCount != 3 || stat.ConsecutiveFailures != 3 || stat.LastError != "Reader is broken." || stat.LastSuccessAt == 0 {
+		t.Fatalf("Status is incorrect. Stat: %+v", stat)
+	}
+	if _, ok := stat.Duration["P99"]; !ok {
+		t.Fatalf("Status should contain duration percentiles. Stat: %+v", stat)
+	}
+
+	failures, ok := agent.MetricsRegistry.Get(metricsName(path, "Failures")).(metrics.Counter)
+	if !ok || failures.Count() != 3 {
+		t.Fatalf("Failures should be fed into metrics registry.")
+	}
+
+	agent.recordRunStat(config, time.Now(), nil)
+
+	if resp := get("/health"); resp.Code != 200 {
+		t.Fatalf("Agent should recover after a successful run. Status: %v", resp.Code)
+	}
+
+	for i := 0; i < UnhealthyConsecutiveFailures; i++ {
+		agent.recordRunStat(config, time.Now(), errors.New("Reader is broken."))
+	}
+
+	agent.Lock()
+	agent.Configs = agent.Configs.WithoutConfig(config.Key())
+	agent.Unlock()
+	agent.pruneRunStats()
+
+	if resp := get("/health"); resp.Code != 200 {
+		t.Fatalf("Removed config should not keep agent unhealthy. Status: %v", resp.Code)
+	}
+	if agent.MetricsRegistry.Get(metricsName(path, "Failures")) != nil {
+		t.Fatalf("Metrics of removed config should be unregistered.")
+	}
+}
+
+func TestRunTimeoutAndSkippedRuns(t *testing.T) {
+	agent := createAgentForTest(t)
+
+	config := resourced_config.Config{
+		GoStruct:       "Shell",
+		GoStructFields: map[string]interface{}{"Command": "sleep 5"},
+		Path:           "/slow",
+		Interval:       "1m",
+		Timeout:        "200ms",
+		Kind:           "reader",
+	}
+
+	agent.runSlots = make(chan struct{}, 1)
+	agent.acquireRunSlot(context.Background())
+
+	startedAt := time.Now()
+
+	done := make(chan struct{})
+	go func() {
+		agent.runExclusive(config, agent.releaseRunSlot)
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+
+	skippedSlot := make(chan struct{}, 1)
+	_, err := agent.runExclusive(config, func() { skippedSlot <- struct{}{} })
+	if err != ErrAlreadyRunning || len(skippedSlot) != 1 {
+		t.Fatalf("Concurrent run should be skipped and give back its slot. Error: %v", err)
+	}
+
+	<-done
+	if time.Since(startedAt) > time.Second {
+		t.Fatalf("Run should stop waiting after Timeout. Duration: %v", time.Since(startedAt))
+	}
+
+	record, _ := agent.GetRunByPath("/r/slow")
+	if !strings.Contains(string(record), "Run timed out after 200ms.") {
+		t.Errorf("Timeout should be saved as error. Record: %s", record)
+	}
+
+	stat := agent.RunStats()["/r/slow"]
+	if stat.TimeoutCount != 1 || stat.FailureCount != 1 || stat.SkippedCount != 1 || stat.LastSkippedAt == 0 {
+		t.Errorf("Timeouts and skipped runs should be counted. Stat: %+v", stat)
+	}
+
+	// The command is killed on timeout, so the run ends and gives back its slot long before sleep 5 would.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if agent.acquireRunSlot(ctx) != nil {
+		t.Fatalf("Timed out run should be killed and release its run slot.")
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if agent.acquireRunSlot(ctx) == nil {
+		t.Errorf("Run slots should be bounded by Runs.MaxConcurrency.")
+	}
+
+	agent.releaseRunSlot()
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if agent.acquireRunSlot(ctx) != nil {
+		t.Errorf("Released run slot should be available.")
+	}
+}
@@ -5,18 +5,30 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/julienschmidt/httprouter"
 	resourced_config "github.com/resourced/resourced/config"
+	"github.com/resourced/resourced/executors"
 	"github.com/resourced/resourced/libhttp"
+	"github.com/resourced/resourced/libtime"
 )
 
-// AuthorizeMiddleware wraps all other handlers; returns 403 for clients that aren't authorized to connect.
+// AuthorizeMiddleware wraps all other handlers; returns 401 for clients that aren't authorized to connect,
+// and 403 for access tokens without the scope required by the request.
+// Without access tokens, every request is allowed except the config API, which would let anyone run commands.
 func (a *Agent) AuthorizeMiddleware(h httprouter.Handle) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		// Immediately forward request if there's no AccessTokens.
-		if a.AccessTokens == nil || len(a.AccessTokens) == 0 {
+		if len(a.accessTokens()) == 0 {
+			if requiredScope(r.Method, r.URL.Path) == ScopeAdmin {
+				libhttp.HandleErrorJsonWithStatusCode(w, ErrAdminScopeRequired, http.StatusForbidden)
+				return
+			}
+
 			h(w, r, ps)
 			return
 		}
@@ -34,11 +46,15 @@ func (a *Agent) AuthorizeMiddleware(h httprouter.Handle) httprouter.Handle {
 			return
 		}
 
-		if !a.IsAllowed(accessTokenString) {
-			err := errors.New("You are not authorized to connect")
+		statusCode, err := a.Authorize(accessTokenString, r.Method, r.URL.Path)
+		if statusCode == http.StatusUnauthorized {
 			libhttp.BasicAuthUnauthorized(w, err)
 			return
 		}
+		if err != nil {
+			libhttp.HandleErrorJsonWithStatusCode(w, err, statusCode)
+			return
+		}
 
 		// Forward request to given handle
 		h(w, r, ps)
@@ -61,163 +77,133 @@ func (a *Agent) RootGetHandler() func(w http.ResponseWriter, r *http.Request, ps
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		w.Header().Set("Content-Type", "application/json")
 
-		arrayOfReaderJsonString := a.allReadersJsonString()
-
-		arrayOfWriterJsonString := a.allWritersJsonString()
-
-		arrayOfExecutorJsonString := a.allExecutorsJsonString()
-
-		arrayOfLogJsonString := a.allLogsJsonString()
-
-		if arrayOfReaderJsonString == "[]" && arrayOfWriterJsonString == "[]" && arrayOfExecutorJsonString == "[]" && arrayOfLogJsonString == "[]" {
-			w.WriteHeader(404)
-			w.Write([]byte(fmt.Sprintf(`{"Error": "Run data does not exist."}`)))
-
-		} else {
-			w.WriteHeader(200)
-			w.Write([]byte(fmt.Sprintf(`{"Readers": %v, "Writers": %v, "Executors": %v, "Loggers": %v}`, arrayOfReaderJsonString, arrayOfWriterJsonString, arrayOfExecutorJsonString, arrayOfLogJsonString)))
+		query, err := NewRecordQuery(r.URL.Query(), a.tags())
+		if err != nil {
+			libhttp.HandleErrorJsonWithStatusCode(w, err, 400)
+			return
 		}
-	}
-}
 
-func (a *Agent) allReadersJsonString() string {
-	readerJsonBytes := make([]string, 0)
-	arrayOfReaderJsonString := "[]"
+		payload := make(map[string][]json.RawMessage)
 
-	for _, config := range a.Configs.Readers {
-		jsonData, err := a.GetRunByPath(config.PathWithPrefix())
-		if err == nil && jsonData != nil {
-			readerJsonBytes = append(readerJsonBytes, string(jsonData))
-		}
-	}
-	if len(readerJsonBytes) > 0 {
-		arrayOfReaderJsonString = "[" + strings.Join(readerJsonBytes, ",") + "]"
-	}
+		var queryErr error
 
-	return arrayOfReaderJsonString
-}
+		allConfigs := a.configs()
 
-// ReadersGetHandler returns all readers data stored in memory.
-func (a *Agent) ReadersGetHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-		w.Header().Set("Content-Type", "application/json")
+		for kind, configs := range map[string][]resourced_config.Config{
+			"Readers":   allConfigs.Readers,
+			"Writers":   allConfigs.Writers,
+			"Executors": allConfigs.Executors,
+			"Loggers":   allConfigs.Loggers,
+		} {
+			records, err := a.allRecords(configs, query)
+			if err != nil && queryErr == nil {
+				queryErr = err
+			}
+			payload[kind] = records
+		}
 
-		arrayOfReaderJsonString := a.allReadersJsonString()
+		if len(payload["Readers"]) == 0 && len(payload["Writers"]) == 0 && len(payload["Executors"]) == 0 && len(payload["Loggers"]) == 0 {
+			if queryErr != nil {
+				libhttp.HandleErrorJsonWithStatusCode(w, queryErr, 400)
+				return
+			}
 
-		if arrayOfReaderJsonString == "[]" {
 			w.WriteHeader(404)
-			w.Write([]byte(fmt.Sprintf(`{"Error": "Run data does not exist."}`)))
-
-		} else {
-			w.WriteHeader(200)
-			w.Write([]byte(arrayOfReaderJsonString))
+			w.Write([]byte(`{"Error": "Run data does not exist."}`))
+			return
 		}
-	}
-}
-
-func (a *Agent) allWritersJsonString() string {
-	writerJsonBytes := make([]string, 0)
-	arrayOfWriterJsonString := "[]"
 
-	for _, config := range a.Configs.Writers {
-		jsonData, err := a.GetRunByPath(config.PathWithPrefix())
-		if err == nil && jsonData != nil {
-			writerJsonBytes = append(writerJsonBytes, string(jsonData))
+		payloadJson, err := json.Marshal(payload)
+		if err != nil {
+			libhttp.HandleErrorJson(w, err)
+			return
 		}
-	}
-	if len(writerJsonBytes) > 0 {
-		arrayOfWriterJsonString = "[" + strings.Join(writerJsonBytes, ",") + "]"
-	}
 
-	return arrayOfWriterJsonString
+		a.writeData(w, r, payloadJson)
+	}
 }
 
-// WritersGetHandler returns all writers data stored in memory.
-func (a *Agent) WritersGetHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-		w.Header().Set("Content-Type", "application/json")
-
-		arrayOfWriterJsonString := a.allWritersJsonString()
+// allRecords returns stored records of configs, in the order of configs.
+// Records the query does not apply to are left out.
+// When the query fails on every stored record, e.g. because of a typo in select, its error is returned.
+func (a *Agent) allRecords(configs []resourced_config.Config, query *RecordQuery) ([]json.RawMessage, error) {
+	records := make([]json.RawMessage, 0)
 
-		if arrayOfWriterJsonString == "[]" {
-			w.WriteHeader(404)
-			w.Write([]byte(fmt.Sprintf(`{"Error": "Run data does not exist."}`)))
+	var queryErr error
 
-		} else {
-			w.WriteHeader(200)
-			w.Write([]byte(arrayOfWriterJsonString))
+	for _, config := range configs {
+		jsonData, err := a.GetRunByPath(config.PathWithPrefix())
+		if err != nil || jsonData == nil {
+			continue
 		}
-	}
-}
-
-func (a *Agent) allExecutorsJsonString() string {
-	executorJsonBytes := make([]string, 0)
-	arrayOfExecutorJsonString := "[]"
 
-	for _, config := range a.Configs.Executors {
-		jsonData, err := a.GetRunByPath(config.PathWithPrefix())
-		if err == nil && jsonData != nil {
-			executorJsonBytes = append(executorJsonBytes, string(jsonData))
+		jsonData, err = query.Apply(jsonData)
+		if err != nil {
+			if queryErr == nil {
+				queryErr = err
+			}
+			continue
 		}
-	}
-	if len(executorJsonBytes) > 0 {
-		arrayOfExecutorJsonString = "[" + strings.Join(executorJsonBytes, ",") + "]"
+		records = append(records, jsonData)
 	}
 
-	return arrayOfExecutorJsonString
+	if len(records) > 0 {
+		return records, nil
+	}
+	return records, queryErr
 }
 
-// ExecutorsGetHandler returns all executors data stored in memory.
-func (a *Agent) ExecutorsGetHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+// allRecordsHandler returns a function that renders stored records of configs.
+func (a *Agent) allRecordsHandler(configs func() []resourced_config.Config) func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		w.Header().Set("Content-Type", "application/json")
 
-		arrayOfExecutorJsonString := a.allExecutorsJsonString()
+		query, err := NewRecordQuery(r.URL.Query(), a.tags())
+		if err != nil {
+			libhttp.HandleErrorJsonWithStatusCode(w, err, 400)
+			return
+		}
+
+		records, err := a.allRecords(configs(), query)
+		if err != nil {
+			libhttp.HandleErrorJsonWithStatusCode(w, err, 400)
+			return
+		}
 
-		if arrayOfExecutorJsonString == "[]" {
+		if len(records) == 0 {
 			w.WriteHeader(404)
-			w.Write([]byte(fmt.Sprintf(`{"Error": "Run data does not exist."}`)))
+			w.Write([]byte(`{"Error": "Run data does not exist."}`))
+			return
+		}
 
-		} else {
-			w.WriteHeader(200)
-			w.Write([]byte(arrayOfExecutorJsonString))
+		recordsJson, err := json.Marshal(records)
+		if err != nil {
+			libhttp.HandleErrorJson(w, err)
+			return
 		}
+
+		a.writeData(w, r, recordsJson)
 	}
 }
 
-func (a *Agent) allLogsJsonString() string {
-	logJsonBytes := make([]string, 0)
-	arrayOfLogJsonString := "[]"
+// ReadersGetHandler returns all readers data stored in memory.
+func (a *Agent) ReadersGetHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return a.allRecordsHandler(func() []resourced_config.Config { return a.configs().Readers })
+}
 
-	for _, config := range a.Configs.Loggers {
-		jsonData, err := a.GetRunByPath(config.PathWithPrefix())
-		if err == nil && jsonData != nil {
-			logJsonBytes = append(logJsonBytes, string(jsonData))
-		}
-	}
-	if len(logJsonBytes) > 0 {
-		arrayOfLogJsonString = "[" + strings.Join(logJsonBytes, ",") + "]"
-	}
+// WritersGetHandler returns all writers data stored in memory.
+func (a *Agent) WritersGetHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return a.allRecordsHandler(func() []resourced_config.Config { return a.configs().Writers })
+}
 
-	return arrayOfLogJsonString
+// ExecutorsGetHandler returns all executors data stored in memory.
+func (a *Agent) ExecutorsGetHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return a.allRecordsHandler(func() []resourced_config.Config { return a.configs().Executors })
 }
 
 // LogsGetHandler returns all logs data stored in memory.
 func (a *Agent) LogsGetHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-		w.Header().Set("Content-Type", "application/json")
-
-		arrayOfExecutorJsonString := a.allLogsJsonString()
-
-		if arrayOfExecutorJsonString == "[]" {
-			w.WriteHeader(404)
-			w.Write([]byte(fmt.Sprintf(`{"Error": "Run data does not exist."}`)))
-
-		} else {
-			w.WriteHeader(200)
-			w.Write([]byte(arrayOfExecutorJsonString))
-		}
-	}
+	return a.allRecordsHandler(func() []resourced_config.Config { return a.configs().Loggers })
 }
 
 // PathsGetHandler returns function that shows all the paths.
@@ -248,15 +234,16 @@ func (a *Agent) PathsGetHandler() func(w http.ResponseWriter, r *http.Request, p
 
 // all /r/readers + /r/graphite
 func (a *Agent) allReaderPaths() []string {
-	payload := make([]string, len(a.Configs.Readers)+1)
+	configs := a.configs().Readers
+	payload := make([]string, len(configs)+1)
 
-	for i, config := range a.Configs.Readers {
+	for i, config := range configs {
 		if config.Path != "" {
 			payload[i] = config.PathWithPrefix()
 		}
 	}
 
-	payload[len(a.Configs.Readers)] = "/r/graphite"
+	payload[len(configs)] = "/r/graphite"
 
 	return payload
 }
@@ -284,9 +271,10 @@ func (a *Agent) ReaderPathsGetHandler() func(w http.ResponseWriter, r *http.Requ
 }
 
 func (a *Agent) allWriterPaths() []string {
-	payload := make([]string, len(a.Configs.Writers))
+	configs := a.configs().Writers
+	payload := make([]string, len(configs))
 
-	for i, config := range a.Configs.Writers {
+	for i, config := range configs {
 		if config.Path != "" {
 			payload[i] = config.PathWithPrefix()
 		}
@@ -318,9 +306,10 @@ func (a *Agent) WriterPathsGetHandler() func(w http.ResponseWriter, r *http.Requ
 }
 
 func (a *Agent) allExecutorPaths() []string {
-	payload := make([]string, len(a.Configs.Executors))
+	configs := a.configs().Executors
+	payload := make([]string, len(configs))
 
-	for i, config := range a.Configs.Executors {
+	for i, config := range configs {
 		if config.Path != "" {
 			payload[i] = config.PathWithPrefix()
 		}
@@ -351,16 +340,39 @@ func (a *Agent) ExecutorPathsGetHandler() func(w http.ResponseWriter, r *http.Re
 	}
 }
 
+// ExecutorStatesGetHandler returns function that shows alert states of all executors.
+func (a *Agent) ExecutorStatesGetHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+
+		payload := make(map[string]executors.State)
+
+		for path, state := range a.ExecutorStateDB.All() {
+			payload["/x"+path] = state
+		}
+
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			w.WriteHeader(500)
+			w.Write([]byte(fmt.Sprintf(`{"Error": "%v"}`, err)))
+			return
+		}
+
+		a.writeData(w, r, payloadBytes)
+	}
+}
+
 func (a *Agent) allLogPaths() []string {
-	payload := make([]string, len(a.Configs.Loggers)+1)
+	configs := a.configs().Loggers
+	payload := make([]string, len(configs)+1)
 
-	for i, config := range a.Configs.Loggers {
+	for i, config := range configs {
 		if config.Path != "" {
 			payload[i] = config.PathWithPrefix()
 		}
 	}
 
-	payload[len(a.Configs.Loggers)] = "/logs/tcp"
+	payload[len(configs)] = "/logs/tcp"
 
 	return payload
 }
@@ -393,11 +405,28 @@ func (a *Agent) handlerByPath(path string, config resourced_config.Config) func(
 		return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 			w.Header().Set("Content-Type", "application/json")
 
+			query := r.URL.Query()
+			if query.Get("from") != "" || query.Get("to") != "" || query.Get("limit") != "" {
+				a.historyHandlerByPath(path)(w, r, ps)
+				return
+			}
+
+			recordQuery, err := NewRecordQuery(query, a.tags())
+			if err != nil {
+				libhttp.HandleErrorJsonWithStatusCode(w, err, 400)
+				return
+			}
+
 			jsonData, err := a.GetRunByPath(path)
 
 			if err == nil && jsonData != nil {
-				w.WriteHeader(200)
-				w.Write(jsonData)
+				jsonData, err = recordQuery.Apply(jsonData)
+				if err != nil {
+					libhttp.HandleErrorJsonWithStatusCode(w, err, 400)
+					return
+				}
+
+				a.writeData(w, r, jsonData)
 			} else if err != nil {
 				w.WriteHeader(503)
 				w.Write([]byte(fmt.Sprintf(`{"Error": "%v"}`, err)))
@@ -414,11 +443,124 @@ func (a *Agent) handlerByPath(path string, config resourced_config.Config) func(
 	}
 }
 
+// historyHandlerByPath returns a function that renders past runs of a path within ?from=...&to=...&limit=...
+func (a *Agent) historyHandlerByPath(path string) func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+
+		query := r.URL.Query()
+		now := time.Now()
+
+		var from, to time.Time
+		var limit int
+		var err error
+
+		if query.Get("from") != "" {
+			from, err = libtime.ParseTimeOrAgo(query.Get("from"), now)
+			if err != nil {
+				libhttp.HandleErrorJsonWithStatusCode(w, err, 400)
+				return
+			}
+		}
+
+		if query.Get("to") != "" && query.Get("to") != "now" {
+			to, err = libtime.ParseTimeOrAgo(query.Get("to"), now)
+			if err != nil {
+				libhttp.HandleErrorJsonWithStatusCode(w, err, 400)
+				return
+			}
+		}
+
+		if query.Get("limit") != "" {
+			limit, err = strconv.Atoi(query.Get("limit"))
+			if err != nil || limit <= 0 {
+				libhttp.HandleErrorJsonWithStatusCode(w, errors.New("limit must be a positive integer"), 400)
+				return
+			}
+		}
+
+		recordQuery, err := NewRecordQuery(query, a.tags())
+		if err != nil {
+			libhttp.HandleErrorJsonWithStatusCode(w, err, 400)
+			return
+		}
+
+		runs := a.GetRunsByPathInRange(path, from, to, limit)
+
+		if len(runs) == 0 {
+			w.WriteHeader(404)
+			w.Write([]byte(fmt.Sprintf(`{"Error": "Run data does not exist.", "Path": "%v"}`, path)))
+			return
+		}
+
+		runsInString := make([]string, len(runs))
+		for i, run := range runs {
+			run, err = recordQuery.Apply(run)
+			if err != nil {
+				libhttp.HandleErrorJsonWithStatusCode(w, err, 400)
+				return
+			}
+			runsInString[i] = string(run)
+		}
+
+		a.writeData(w, r, []byte("["+strings.Join(runsInString, ",")+"]"))
+	}
+}
+
+// runHandlerByConfig returns function that executes config right away and shows its fresh run data.
+// Concurrent runs of the same config are rejected with 409.
+func (a *Agent) runHandlerByConfig(config resourced_config.Config) func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+
+		output, err := a.RunExclusive(config)
+		if err == ErrAlreadyRunning {
+			libhttp.HandleErrorJsonWithStatusCode(w, err, 409)
+			return
+		}
+		if err != nil {
+			// Errors of readers, writers, and executors may quote their GoStructFields.
+			libhttp.HandleErrorJson(w, errors.New(resourced_config.Redact(err.Error())))
+			return
+		}
+
+		// Executors whose conditions are not met have nothing to show.
+		if output == nil {
+			w.WriteHeader(204)
+			return
+		}
+
+		jsonData, err := a.GetRunByPath(config.PathWithPrefix())
+		if err != nil {
+			libhttp.HandleErrorJson(w, err)
+			return
+		}
+
+		a.writeData(w, r, jsonData)
+	}
+}
+
+// MapRunPostHandlers returns on-demand run handlers of readers, writers, and executors, keyed by path + "/run".
+func (a *Agent) MapRunPostHandlers() map[string]func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	handlersMap := make(map[string]func(w http.ResponseWriter, r *http.Request, ps httprouter.Params))
+
+	allConfigs := a.configs()
+
+	for _, configs := range [][]resourced_config.Config{allConfigs.Readers, allConfigs.Writers, allConfigs.Executors} {
+		for _, config := range configs {
+			if config.Path != "" {
+				handlersMap[config.PathWithPrefix()+"/run"] = a.runHandlerByConfig(config)
+			}
+		}
+	}
+	return handlersMap
+}
+
 // MapReadersGetHandlers returns functions that handle readers paths.
 func (a *Agent) MapReadersGetHandlers() map[string]func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	handlersMap := make(map[string]func(w http.ResponseWriter, r *http.Request, ps httprouter.Params))
 
-	for _, config := range a.Configs.Readers {
+	for _, config := range a.configs().Readers {
 		if config.Path != "" {
 			path := config.PathWithPrefix()
 			handlersMap[path] = a.handlerByPath(path, config)
@@ -431,7 +573,7 @@ func (a *Agent) MapReadersGetHandlers() map[string]func(w http.ResponseWriter, r
 func (a *Agent) MapWritersGetHandlers() map[string]func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	handlersMap := make(map[string]func(w http.ResponseWriter, r *http.Request, ps httprouter.Params))
 
-	for _, config := range a.Configs.Writers {
+	for _, config := range a.configs().Writers {
 		if config.Path != "" {
 			path := config.PathWithPrefix()
 			handlersMap[path] = a.handlerByPath(path, config)
@@ -444,7 +586,7 @@ func (a *Agent) MapWritersGetHandlers() map[string]func(w http.ResponseWriter, r
 func (a *Agent) MapExecutorsGetHandlers() map[string]func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	handlersMap := make(map[string]func(w http.ResponseWriter, r *http.Request, ps httprouter.Params))
 
-	for _, config := range a.Configs.Executors {
+	for _, config := range a.configs().Executors {
 		if config.Path != "" {
 			path := config.PathWithPrefix()
 			handlersMap[path] = a.handlerByPath(path, config)
@@ -457,7 +599,7 @@ func (a *Agent) MapExecutorsGetHandlers() map[string]func(w http.ResponseWriter,
 func (a *Agent) MapLogsGetHandlers() map[string]func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	handlersMap := make(map[string]func(w http.ResponseWriter, r *http.Request, ps httprouter.Params))
 
-	for _, config := range a.Configs.Loggers {
+	for _, config := range a.configs().Loggers {
 		if config.Path != "" {
 			path := config.PathWithPrefix()
 			handlersMap[path] = a.handlerByPath(path, config)
@@ -476,8 +618,7 @@ func (a *Agent) ReadersGraphiteGetHandler() func(w http.ResponseWriter, r *http.
 			w.WriteHeader(500)
 			w.Write([]byte(fmt.Sprintf(`{"Error": "%v"}`, err.Error())))
 		} else {
-			w.WriteHeader(200)
-			w.Write(dataInBytes)
+			a.writeData(w, r, dataInBytes)
 		}
 	}
 }
@@ -493,8 +634,7 @@ func (a *Agent) LogsTCPGetHandler() func(w http.ResponseWriter, r *http.Request,
 			w.WriteHeader(500)
 			w.Write([]byte(fmt.Sprintf(`{"Error": "%v"}`, err.Error())))
 		} else {
-			w.WriteHeader(200)
-			w.Write(dataInBytes)
+			a.writeData(w, r, dataInBytes)
 		}
 	}
 }
@@ -506,6 +646,11 @@ func (a *Agent) HttpRouter() *httprouter.Router {
 	router.HEAD("/", a.AuthorizeMiddleware(a.RootHeadHandler()))
 	router.GET("/", a.AuthorizeMiddleware(a.RootGetHandler()))
 	router.GET("/paths", a.AuthorizeMiddleware(a.PathsGetHandler()))
+	router.GET("/metrics", a.AuthorizeMiddleware(a.MetricsGetHandler()))
+	router.GET("/health", a.AuthorizeMiddleware(a.HealthGetHandler()))
+	router.GET("/status", a.AuthorizeMiddleware(a.StatusGetHandler()))
+	router.GET("/stream", a.AuthorizeMiddleware(a.StreamGetHandler()))
+	router.GET("/gostructs", a.AuthorizeMiddleware(a.GoStructsGetHandler()))
 
 	router.GET("/r", a.AuthorizeMiddleware(a.ReadersGetHandler()))
 	router.GET("/r/paths", a.AuthorizeMiddleware(a.ReaderPathsGetHandler()))
@@ -516,6 +661,15 @@ func (a *Agent) HttpRouter() *httprouter.Router {
 
 	router.GET("/x", a.AuthorizeMiddleware(a.ExecutorsGetHandler()))
 	router.GET("/x/paths", a.AuthorizeMiddleware(a.ExecutorPathsGetHandler()))
+	router.GET("/x/states", a.AuthorizeMiddleware(a.ExecutorStatesGetHandler()))
+	router.GET("/x/silences", a.AuthorizeMiddleware(a.SilencesGetHandler()))
+	router.POST("/x/silences", a.AuthorizeMiddleware(a.SilencesPostHandler()))
+	router.DELETE("/x/silences/:id", a.AuthorizeMiddleware(a.SilenceDeleteHandler()))
+
+	router.GET("/api/configs", a.AuthorizeMiddleware(a.ConfigsGetHandler()))
+	router.GET("/api/configs/:kind/:name", a.AuthorizeMiddleware(a.ConfigGetHandler()))
+	router.PUT("/api/configs/:kind/:name", a.AuthorizeMiddleware(a.ConfigPutHandler()))
+	router.DELETE("/api/configs/:kind/:name", a.AuthorizeMiddleware(a.ConfigDeleteHandler()))
 
 	router.GET("/logs", a.AuthorizeMiddleware(a.LogsGetHandler()))
 	router.GET("/logs/paths", a.AuthorizeMiddleware(a.LogPathsGetHandler()))
@@ -537,5 +691,45 @@ func (a *Agent) HttpRouter() *httprouter.Router {
 		router.GET(path, a.AuthorizeMiddleware(handler))
 	}
 
+	for path, handler := range a.MapRunPostHandlers() {
+		router.POST(path, a.AuthorizeMiddleware(handler))
+	}
+
+	for path, handler := range a.MapStreamGetHandlers() {
+		router.GET(path, a.AuthorizeMiddleware(handler))
+	}
+
 	return router
 }
+
+// setRouter replaces the router used by ServeHTTP.
+func (a *Agent) setRouter(router *httprouter.Router) {
+	a.Lock()
+	a.router = router
+	a.Unlock()
+}
+
+// ServeHTTP dispatches requests to the current HTTP router.
+// The router is rebuilt every time configs are reloaded, so that new paths are served and removed paths are not.
+func (a *Agent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.RLock()
+	router := a.router
+	a.RUnlock()
+
+	if router == nil {
+		router = a.HttpRouter()
+		a.setRouter(router)
+	}
+
+	logFields := logrus.Fields{
+		"Method":     r.Method,
+		"URL":        r.URL.String(),
+		"RemoteAddr": r.RemoteAddr,
+	}
+	if clientIdentity := ClientIdentity(r.TLS); clientIdentity != "" {
+		logFields["ClientIdentity"] = clientIdentity
+	}
+	logrus.WithFields(logFields).Debug("Serving HTTP request")
+
+	router.ServeHTTP(w, r)
+}
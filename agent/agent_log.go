@@ -89,12 +89,33 @@ func (a *Agent) PruneLogs(autoPrunner IAutoPrune, logdb *libmap.TSafeMapStrings)
 }
 
 // SendTCPLogForever sends log lines to master in an infinite loop.
+// Remaining log lines are sent one last time when the agent shuts down.
 func (a *Agent) SendTCPLogForever(config resourced_config.LogReceiverConfig) {
+	interval := config.WriteToMasterInterval
+	if interval == "" {
+		interval = "1m"
+	}
+
+	scheduler, err := libtime.NewScheduler(interval, false, "", "", "")
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"Error": err.Error(),
+		}).Error("Failed to parse LogReceiver.WriteToMasterInterval")
+		return
+	}
+
+	a.wg.Add(1)
 	go func(a *Agent, config resourced_config.LogReceiverConfig) {
+		defer a.wg.Done()
+
 		for {
+			if scheduler.Wait(a.ctx) != nil {
+				a.SendLog(a.TCPLogDB, "")
+				return
+			}
+
 			a.SendLog(a.TCPLogDB, "")
 			a.PruneLogs(config, a.TCPLogDB)
-			libtime.SleepString(config.WriteToMasterInterval)
 		}
 	}(a, config)
 }
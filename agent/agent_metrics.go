@@ -5,8 +5,9 @@ import (
 	"time"
 )
 
+// NewMetricsRegistry adds runtime stats to the agent registry, which also holds per-config run stats.
 func (a *Agent) NewMetricsRegistry() metrics.Registry {
-	r := metrics.NewRegistry()
+	r := a.MetricsRegistry
 	metrics.RegisterDebugGCStats(r)
 	metrics.RegisterRuntimeMemStats(r)
 
@@ -0,0 +1,301 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	resourced_config "github.com/resourced/resourced/config"
+)
+
+// prometheusLabelNames names the labels given to keys of maps-of-maps, outermost first, per GoStruct.
+// Collections of other readers are labeled "key", "key_2", and so on.
+var prometheusLabelNames = map[string][]string{
+	"DockerContainersCpu":    []string{"container"},
+	"DockerContainersMemory": []string{"container"},
+	"DockerContainersNetDev": []string{"container", "interface"},
+	"Df":                     []string{"path"},
+	"Du":                     []string{"path"},
+	"DiskIO":                 []string{"device"},
+	"ProcDiskStats":          []string{"device"},
+	"NetIO":                  []string{"interface"},
+	"ProcNetDev":             []string{"interface"},
+	"ProcNetDevPid":          []string{"interface"},
+}
+
+var prometheusInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+var prometheusIdentifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+type prometheusLabel struct {
+	Name  string
+	Value string
+}
+
+type prometheusSample struct {
+	Name   string
+	Labels []prometheusLabel
+	Value  float64
+}
+
+// prometheusName turns an arbitrary string into a valid Prometheus metric or label name.
+func prometheusName(input string) string {
+	name := strings.Trim(prometheusInvalidChars.ReplaceAllString(input, "_"), "_")
+
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+
+	return name
+}
+
+// withLabel returns a copy of labels with one more label.
+// A name already taken, e.g. "index" of nested lists, gets a suffix: "index_2", "index_3", and so on.
+func withLabel(labels []prometheusLabel, name, value string) []prometheusLabel {
+	taken := func(candidate string) bool {
+		for _, label := range labels {
+			if label.Name == candidate {
+				return true
+			}
+		}
+		return false
+	}
+
+	unique := name
+	for i := 2; taken(unique); i++ {
+		unique = fmt.Sprintf("%v_%v", name, i)
+	}
+
+	return append(append([]prometheusLabel{}, labels...), prometheusLabel{Name: unique, Value: value})
+}
+
+// isKeyedCollection decides if a map is a collection keyed by data (e.g. container names),
+// rather than a struct with fixed field names.
+// When labeled is true, the GoStruct names a label for this depth, and any map of maps is a collection,
+// so metric names do not depend on the number of entries, e.g. of network interfaces.
+// Otherwise it is a collection when all values are maps with the same keys and
+// either there are many entries or the keys cannot be part of a metric name.
+func isKeyedCollection(data map[string]interface{}, labeled bool) bool {
+	if len(data) == 0 {
+		return false
+	}
+
+	if labeled {
+		for _, value := range data {
+			if _, ok := value.(map[string]interface{}); !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	var childKeys []string
+	allIdentifiers := true
+
+	for key, value := range data {
+		child, ok := value.(map[string]interface{})
+		if !ok {
+			return false
+		}
+
+		keys := make([]string, 0, len(child))
+		for childKey := range child {
+			keys = append(keys, childKey)
+		}
+		sort.Strings(keys)
+
+		if childKeys == nil {
+			childKeys = keys
+		} else if strings.Join(childKeys, "\x00") != strings.Join(keys, "\x00") {
+			return false
+		}
+
+		if !prometheusIdentifier.MatchString(key) {
+			allIdentifiers = false
+		}
+	}
+
+	return len(data) > 1 || !allIdentifiers
+}
+
+// flattenPrometheus walks JSON data and collects every numeric leaf as a sample.
+// When detectCollections is true, maps-of-maps are turned into labels instead of name parts.
+func flattenPrometheus(name string, labels []prometheusLabel, labelNames []string, depth int, data interface{}, detectCollections bool, samples *[]prometheusSample) {
+	switch value := data.(type) {
+	case float64:
+		*samples = append(*samples, prometheusSample{Name: name, Labels: labels, Value: value})
+
+	case bool:
+		sample := prometheusSample{Name: name, Labels: labels}
+		if value {
+			sample.Value = 1
+		}
+		*samples = append(*samples, sample)
+
+	case []interface{}:
+		for i, child := range value {
+			childLabels := withLabel(labels, "index", strconv.Itoa(i))
+			flattenPrometheus(name, childLabels, labelNames, depth, child, detectCollections, samples)
+		}
+
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		if detectCollections && isKeyedCollection(value, depth < len(labelNames)) {
+			labelName := "key"
+			if depth < len(labelNames) {
+				labelName = labelNames[depth]
+			} else if depth > 0 {
+				labelName = fmt.Sprintf("key_%v", depth+1)
+			}
+
+			for _, key := range keys {
+				childLabels := withLabel(labels, labelName, key)
+				flattenPrometheus(name, childLabels, labelNames, depth+1, value[key], detectCollections, samples)
+			}
+			return
+		}
+
+		for _, key := range keys {
+			flattenPrometheus(name+"_"+prometheusName(key), labels, labelNames, depth, value[key], detectCollections, samples)
+		}
+	}
+}
+
+// prometheusHostLabels returns hostname and tags as labels.
+// Tags are prefixed with "tag_", so they do not collide with host or collection labels.
+func (a *Agent) prometheusHostLabels() []prometheusLabel {
+	labels := make([]prometheusLabel, 0)
+
+	host, err := a.hostData()
+	if err != nil {
+		return labels
+	}
+
+	labels = append(labels, prometheusLabel{Name: "host", Value: host.Name})
+
+	tagKeys := make([]string, 0, len(host.Tags))
+	for key := range host.Tags {
+		tagKeys = append(tagKeys, key)
+	}
+	sort.Strings(tagKeys)
+
+	for _, key := range tagKeys {
+		labels = withLabel(labels, "tag_"+prometheusName(key), host.Tags[key])
+	}
+
+	return labels
+}
+
+// prometheusSamples gathers samples from all readers and graphite data.
+func (a *Agent) prometheusSamples() []prometheusSample {
+	samples := make([]prometheusSample, 0)
+	hostLabels := a.prometheusHostLabels()
+
+	for _, config := range a.configs().Readers {
+		if config.Path == "" {
+			continue
+		}
+
+		jsonData, err := a.GetRunByPath(config.PathWithPrefix())
+		if err != nil || jsonData == nil {
+			continue
+		}
+
+		jsonData, err = resourced_config.RedactJSON(jsonData)
+		if err != nil {
+			continue
+		}
+
+		var record map[string]interface{}
+		err = json.Unmarshal(jsonData, &record)
+		if err != nil {
+			continue
+		}
+
+		name := "resourced_" + prometheusName(config.Path)
+		flattenPrometheus(name, hostLabels, prometheusLabelNames[config.GoStruct], 0, record["Data"], true, &samples)
+	}
+
+	flattenPrometheus("resourced_graphite", hostLabels, nil, 0, a.GraphiteDB.All(), false, &samples)
+
+	return samples
+}
+
+// prometheusEscape escapes label value according to Prometheus text format.
+func prometheusEscape(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	return strings.Replace(value, "\n", `\n`, -1)
+}
+
+// prometheusValue formats float according to Prometheus text format.
+func prometheusValue(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// prometheusText renders samples in Prometheus text exposition format, grouped by metric name.
+func prometheusText(samples []prometheusSample) []byte {
+	groups := make(map[string][]prometheusSample)
+	names := make([]string, 0)
+
+	for _, sample := range samples {
+		if _, ok := groups[sample.Name]; !ok {
+			names = append(names, sample.Name)
+		}
+		groups[sample.Name] = append(groups[sample.Name], sample)
+	}
+	sort.Strings(names)
+
+	var buffer bytes.Buffer
+
+	for _, name := range names {
+		buffer.WriteString(fmt.Sprintf("# TYPE %v gauge\n", name))
+
+		for _, sample := range groups[name] {
+			buffer.WriteString(name)
+
+			if len(sample.Labels) > 0 {
+				labels := make([]string, len(sample.Labels))
+				for i, label := range sample.Labels {
+					labels[i] = fmt.Sprintf(`%v="%v"`, label.Name, prometheusEscape(label.Value))
+				}
+				buffer.WriteString("{" + strings.Join(labels, ",") + "}")
+			}
+
+			buffer.WriteString(" " + prometheusValue(sample.Value) + "\n")
+		}
+	}
+
+	return buffer.Bytes()
+}
+
+// MetricsGetHandler renders all readers and graphite data in Prometheus text format.
+func (a *Agent) MetricsGetHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+		w.WriteHeader(200)
+		w.Write(prometheusText(a.prometheusSamples()))
+	}
+}
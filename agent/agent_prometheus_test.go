@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFlattenPrometheus(t *testing.T) {
+	var data interface{}
+	json.Unmarshal([]byte(`{
+		"4e3b9f0e": {"user": 1.5, "system": 2},
+		"9a7c1d2f": {"user": 3, "system": 4}
+	}`), &data)
+
+	samples := make([]prometheusSample, 0)
+	flattenPrometheus("resourced_docker_containers_cpu", nil, prometheusLabelNames["DockerContainersCpu"], 0, data, true, &samples)
+
+	text := string(prometheusText(samples))
+
+	for _, line := range []string{
+		"# TYPE resourced_docker_containers_cpu_user gauge",
+		`resourced_docker_containers_cpu_user{container="4e3b9f0e"} 1.5`,
+		`resourced_docker_containers_cpu_system{container="9a7c1d2f"} 4`,
+	} {
+		if !strings.Contains(text, line) {
+			t.Errorf("Prometheus text does not contain %v. Text: %v", line, text)
+		}
+	}
+}
+
+func TestFlattenPrometheusSingleEntry(t *testing.T) {
+	var data interface{}
+	json.Unmarshal([]byte(`{"eth0": {"BytesRecv": 10}}`), &data)
+
+	samples := make([]prometheusSample, 0)
+	flattenPrometheus("resourced_net_io", []prometheusLabel{{Name: "interface", Value: "tag"}}, prometheusLabelNames["NetIO"], 0, data, true, &samples)
+
+	text := string(prometheusText(samples))
+
+	if !strings.Contains(text, `resourced_net_io_BytesRecv{interface="tag",interface_2="eth0"} 10`) {
+		t.Errorf("Single entry of a labeled collection should be a label, colliding names should get a suffix. Text: %v", text)
+	}
+}
+
+func TestFlattenPrometheusStruct(t *testing.T) {
+	var data interface{}
+	json.Unmarshal([]byte(`{"LoadAvg1m": 0.5, "Memory": {"Free": 10, "Swap": {"Free": 1}}, "Name": "skipped"}`), &data)
+
+	samples := make([]prometheusSample, 0)
+	flattenPrometheus("resourced_load_avg", []prometheusLabel{{Name: "host", Value: `a"b`}}, nil, 0, data, true, &samples)
+
+	text := string(prometheusText(samples))
+
+	for _, line := range []string{
+		`resourced_load_avg_LoadAvg1m{host="a\"b"} 0.5`,
+		`resourced_load_avg_Memory_Free{host="a\"b"} 10`,
+		`resourced_load_avg_Memory_Swap_Free{host="a\"b"} 1`,
+	} {
+		if !strings.Contains(text, line) {
+			t.Errorf("Prometheus text does not contain %v. Text: %v", line, text)
+		}
+	}
+	if strings.Contains(text, "skipped") {
+		t.Errorf("Prometheus text should not contain string values. Text: %v", text)
+	}
+}
+
+func TestMetricsGetHandler(t *testing.T) {
+	agent := createAgentForTest(t)
+
+	for _, config := range agent.Configs.Readers {
+		if config.GoStruct == "LoadAvg" {
+			_, err := agent.Run(config)
+			if err != nil {
+				t.Fatalf("Run should work. Error: %v", err)
+			}
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	resp := httptest.NewRecorder()
+	agent.HttpRouter().ServeHTTP(resp, req)
+
+	if resp.Code != 200 {
+		t.Fatalf("GET /metrics should work. Status: %v", resp.Code)
+	}
+	if !strings.Contains(resp.Body.String(), "resourced_load_avg_LoadAvg1m{host=") {
+		t.Errorf("GET /metrics should contain load-avg data. Body: %v", resp.Body.String())
+	}
+}
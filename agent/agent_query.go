@@ -0,0 +1,226 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/resourced/resourced/queryparser"
+)
+
+// RecordQuery selects, filters, and projects stored records.
+// It is built from ?select=, ?where=, ?fields=, and ?exclude= query parameters of data endpoints.
+type RecordQuery struct {
+	// Select picks the value to render, e.g. Data.LoadAvg1m. The whole record is rendered when it is empty.
+	Select []string
+
+	// Where filters the selected value, or Data of the record when Select is empty. See queryparser.CompilePredicate.
+	Where queryparser.Node
+
+	// Fields and Exclude keep or remove keys of each filtered element, or of the rendered value when Where is nil.
+	Fields  [][]string
+	Exclude [][]string
+
+	qp *queryparser.QueryParser
+}
+
+// NewRecordQuery parses query parameters. It returns nil when none of them are given.
+func NewRecordQuery(values url.Values, tags map[string]string) (*RecordQuery, error) {
+	if values.Get("select") == "" && values.Get("where") == "" && values.Get("fields") == "" && values.Get("exclude") == "" {
+		return nil, nil
+	}
+
+	q := &RecordQuery{qp: queryparser.New(nil, tags)}
+
+	var err error
+
+	q.Select, err = queryparser.ParseSelector(values.Get("select"))
+	if err != nil {
+		return nil, fmt.Errorf("select: %v", err)
+	}
+
+	if values.Get("where") != "" {
+		q.Where, err = queryparser.CompilePredicate(values.Get("where"))
+		if err != nil {
+			return nil, fmt.Errorf("where: %v", err)
+		}
+	}
+
+	q.Fields, err = parseProjection(values.Get("fields"))
+	if err != nil {
+		return nil, fmt.Errorf("fields: %v", err)
+	}
+
+	q.Exclude, err = parseProjection(values.Get("exclude"))
+	if err != nil {
+		return nil, fmt.Errorf("exclude: %v", err)
+	}
+
+	return q, nil
+}
+
+// parseProjection parses comma separated selectors.
+func parseProjection(projection string) ([][]string, error) {
+	selectors := make([][]string, 0)
+
+	if projection == "" {
+		return selectors, nil
+	}
+
+	for _, selector := range strings.Split(projection, ",") {
+		keys, err := queryparser.ParseSelector(selector)
+		if err != nil {
+			return nil, err
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		for _, key := range keys {
+			if key == "*" {
+				return nil, errors.New("* is not supported")
+			}
+		}
+		selectors = append(selectors, keys)
+	}
+
+	return selectors, nil
+}
+
+// Apply renders the query result of a JSON record. A nil query returns the record as is.
+func (q *RecordQuery) Apply(recordJson []byte) ([]byte, error) {
+	if q == nil {
+		return recordJson, nil
+	}
+
+	var record interface{}
+
+	err := json.Unmarshal(recordJson, &record)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := queryparser.Select(record, q.Select)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Where != nil {
+		value, err = q.filter(value)
+	} else {
+		value = q.project(value)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(value)
+}
+
+// filter applies Where, then projects each of the remaining elements.
+func (q *RecordQuery) filter(value interface{}) (interface{}, error) {
+	// Without select, the collection is Data, and the record is kept around it.
+	if len(q.Select) == 0 {
+		record, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("record does not have Data")
+		}
+
+		data, err := q.filterCollection(record["Data"])
+		if err != nil {
+			return nil, err
+		}
+
+		record["Data"] = data
+		return record, nil
+	}
+
+	return q.filterCollection(value)
+}
+
+func (q *RecordQuery) filterCollection(value interface{}) (interface{}, error) {
+	filtered, err := q.qp.Filter(value, q.Where)
+	if err != nil {
+		return nil, err
+	}
+
+	switch collection := filtered.(type) {
+	case []interface{}:
+		for i, element := range collection {
+			collection[i] = q.projectOne(element)
+		}
+
+	case map[string]interface{}:
+		for key, element := range collection {
+			collection[key] = q.projectOne(element)
+		}
+	}
+
+	return filtered, nil
+}
+
+// project applies Fields and Exclude to value, or to each element when value is a list.
+func (q *RecordQuery) project(value interface{}) interface{} {
+	if list, ok := value.([]interface{}); ok {
+		for i, element := range list {
+			list[i] = q.projectOne(element)
+		}
+		return list
+	}
+
+	return q.projectOne(value)
+}
+
+func (q *RecordQuery) projectOne(value interface{}) interface{} {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+
+	if len(q.Fields) > 0 {
+		projected := make(map[string]interface{})
+
+		for _, keys := range q.Fields {
+			field, err := queryparser.Select(object, keys)
+			if err == nil {
+				setKeys(projected, keys, field)
+			}
+		}
+		object = projected
+	}
+
+	for _, keys := range q.Exclude {
+		deleteKeys(object, keys)
+	}
+
+	return object
+}
+
+// setKeys sets value in nested objects, creating the objects along keys.
+func setKeys(object map[string]interface{}, keys []string, value interface{}) {
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := object[key].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			object[key] = child
+		}
+		object = child
+	}
+
+	object[keys[len(keys)-1]] = value
+}
+
+// deleteKeys removes the last of keys from nested objects.
+func deleteKeys(object map[string]interface{}, keys []string) {
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := object[key].(map[string]interface{})
+		if !ok {
+			return
+		}
+		object = child
+	}
+
+	delete(object, keys[len(keys)-1])
+}
@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/resourced/resourced/executors"
+	"github.com/resourced/resourced/libhttp"
+	"github.com/resourced/resourced/libstring"
+)
+
+// SilencesFilename is the name of silences file inside DbPath.
+const SilencesFilename = "silences.json"
+
+// DefaultStateDir keeps silences when DbPath is empty. RESOURCED_STATE_DIR overrides it.
+const DefaultStateDir = "~/.resourced"
+
+// stateDir returns the directory of silences: DbPath, RESOURCED_STATE_DIR, or DefaultStateDir.
+func (a *Agent) stateDir() string {
+	dir := a.DbPath
+	if dir == "" {
+		dir = os.Getenv("RESOURCED_STATE_DIR")
+	}
+	if dir == "" {
+		dir = DefaultStateDir
+	}
+	return libstring.ExpandTildeAndEnv(dir)
+}
+
+// setSilences loads silences, persisted in stateDir.
+// Agents created by NewInMemory keep silences in memory, like results.
+func (a *Agent) setSilences(inMemory bool) error {
+	filename := ""
+	if !inMemory {
+		filename = filepath.Join(a.stateDir(), SilencesFilename)
+	}
+
+	silenceDB, err := executors.NewSilenceDB(filename)
+	if err != nil {
+		return err
+	}
+
+	a.ExecutorSilenceDB = silenceDB
+	return nil
+}
+
+// silenceRequest is the payload of POST /x/silences.
+type silenceRequest struct {
+	Path     string
+	Tags     map[string]string
+	Duration string
+	Comment  string
+}
+
+// SilencesGetHandler returns function that shows all active silences.
+func (a *Agent) SilencesGetHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+
+		payloadBytes, err := json.Marshal(a.ExecutorSilenceDB.All())
+		if err != nil {
+			libhttp.HandleErrorJson(w, err)
+			return
+		}
+
+		w.WriteHeader(200)
+		w.Write(payloadBytes)
+	}
+}
+
+// SilencesPostHandler returns function that silences executors by path or host tags, for a duration.
+func (a *Agent) SilencesPostHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		var req silenceRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			libhttp.HandleErrorJsonWithStatusCode(w, fmt.Errorf("Silence must be JSON. Error: %v", err), 400)
+			return
+		}
+
+		if req.Duration == "" {
+			libhttp.HandleErrorJsonWithStatusCode(w, errors.New("Silence Duration is required, e.g. 1h."), 400)
+			return
+		}
+
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			libhttp.HandleErrorJsonWithStatusCode(w, err, 400)
+			return
+		}
+
+		silence, err := a.ExecutorSilenceDB.Add(executors.Silence{Path: req.Path, Tags: req.Tags, Comment: req.Comment}, duration)
+		if err != nil && silence.ID == "" {
+			libhttp.HandleErrorJsonWithStatusCode(w, err, 400)
+			return
+		}
+		if err != nil {
+			libhttp.HandleErrorJson(w, err)
+			return
+		}
+
+		payloadBytes, err := json.Marshal(silence)
+		if err != nil {
+			libhttp.HandleErrorJson(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(201)
+		w.Write(payloadBytes)
+	}
+}
+
+// SilenceDeleteHandler returns function that removes a silence by ID.
+func (a *Agent) SilenceDeleteHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		found, err := a.ExecutorSilenceDB.Delete(ps.ByName("id"))
+		if err != nil {
+			libhttp.HandleErrorJson(w, err)
+			return
+		}
+		if !found {
+			libhttp.HandleErrorJsonWithStatusCode(w, fmt.Errorf("Silence %v does not exist.", ps.ByName("id")), 404)
+			return
+		}
+
+		w.WriteHeader(204)
+	}
+}
@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/resourced/resourced/executors"
+)
+
+func TestHttpRouterSilences(t *testing.T) {
+	agent := createAgentForTest(t)
+	router := agent.HttpRouter()
+
+	for body, expectedStatus := range map[string]int{
+		`{"Path": "/uptime", "Duration": "1h", "Comment": "maintenance"}`: 201,
+		`{"Path": "/uptime"}`:                                             400,
+		`{"Duration": "1h"}`:                                              400,
+		`{"Path": "/uptime", "Duration": "soon"}`:                         400,
+		`not json`:                                                        400,
+	} {
+		req, _ := http.NewRequest("POST", "/x/silences", strings.NewReader(body))
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != expectedStatus {
+			t.Fatalf("Silence request has incorrect status. Body: %v, Status: %v", body, resp.Code)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/x/silences", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	var silences []executors.Silence
+	err := json.Unmarshal(resp.Body.Bytes(), &silences)
+	if err != nil || len(silences) != 1 || silences[0].Path != "/x/uptime" {
+		t.Fatalf("There should be 1 silence for /x/uptime. Body: %s", resp.Body.Bytes())
+	}
+
+	req, _ = http.NewRequest("DELETE", "/x/silences/"+silences[0].ID, nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != 204 {
+		t.Fatalf("Deleting silence should work. Status: %v", resp.Code)
+	}
+	if len(agent.ExecutorSilenceDB.All()) != 0 {
+		t.Fatalf("Silence should have been deleted.")
+	}
+}
+
+func TestSilencesPersisted(t *testing.T) {
+	agent := createAgentForTest(t)
+
+	req, _ := http.NewRequest("POST", "/x/silences", strings.NewReader(`{"Path": "/uptime", "Duration": "1h"}`))
+	resp := httptest.NewRecorder()
+	agent.HttpRouter().ServeHTTP(resp, req)
+
+	if resp.Code != 201 {
+		t.Fatalf("Silence request should work. Status: %v", resp.Code)
+	}
+
+	stateDir := agent.stateDir()
+
+	restarted := createAgentForTest(t)
+	os.Setenv("RESOURCED_STATE_DIR", stateDir)
+
+	err := restarted.setSilences(false)
+	if err != nil || len(restarted.ExecutorSilenceDB.All()) != 1 {
+		t.Fatalf("Silences should survive restarts. Error: %v", err)
+	}
+
+	restarted.DbPath = "~/db"
+	if strings.HasPrefix(restarted.stateDir(), "~") {
+		t.Errorf("DbPath should be expanded. stateDir: %v", restarted.stateDir())
+	}
+}
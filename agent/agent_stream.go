@@ -0,0 +1,216 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/julienschmidt/httprouter"
+	resourced_config "github.com/resourced/resourced/config"
+	"github.com/resourced/resourced/libhttp"
+)
+
+// StreamKeepAliveInterval is how often an SSE comment is sent to idle stream clients,
+// so proxies do not close the connection.
+var StreamKeepAliveInterval = 15 * time.Second
+
+// streamBufferSize is how many records a slow stream client may lag behind before records are dropped.
+const streamBufferSize = 64
+
+// streamEvent is a record stored by saveRun.
+type streamEvent struct {
+	Path     string
+	UnixNano int64
+	Data     []byte
+}
+
+// streamSubscriber receives records whose path matches pattern.
+type streamSubscriber struct {
+	pattern string
+	events  chan streamEvent
+}
+
+// streamHub fans out stored records to stream clients.
+type streamHub struct {
+	subscribers map[*streamSubscriber]bool
+	sync.RWMutex
+}
+
+func newStreamHub() *streamHub {
+	return &streamHub{subscribers: make(map[*streamSubscriber]bool)}
+}
+
+// Subscribe registers a subscriber for paths matching pattern. Empty pattern matches every path.
+func (hub *streamHub) Subscribe(pattern string) *streamSubscriber {
+	subscriber := &streamSubscriber{
+		pattern: pattern,
+		events:  make(chan streamEvent, streamBufferSize),
+	}
+
+	hub.Lock()
+	hub.subscribers[subscriber] = true
+	hub.Unlock()
+
+	return subscriber
+}
+
+// Unsubscribe removes subscriber, it no longer receives records.
+func (hub *streamHub) Unsubscribe(subscriber *streamSubscriber) {
+	hub.Lock()
+	delete(hub.subscribers, subscriber)
+	hub.Unlock()
+}
+
+// Len returns the number of subscribers.
+func (hub *streamHub) Len() int {
+	hub.RLock()
+	defer hub.RUnlock()
+
+	return len(hub.subscribers)
+}
+
+// Publish sends a record to every matching subscriber without blocking.
+// Subscribers that are too slow to keep up miss the record.
+func (hub *streamHub) Publish(recordPath string, unixNano int64, data []byte) {
+	event := streamEvent{Path: recordPath, UnixNano: unixNano, Data: data}
+
+	hub.RLock()
+	defer hub.RUnlock()
+
+	for subscriber := range hub.subscribers {
+		if !matchStreamPath(subscriber.pattern, recordPath) {
+			continue
+		}
+
+		select {
+		case subscriber.events <- event:
+		default:
+			logrus.WithFields(logrus.Fields{
+				"Path": recordPath,
+			}).Debug("Stream client is too slow, dropping record")
+		}
+	}
+}
+
+// matchStreamPath checks recordPath against a path.Match pattern. A trailing * also matches any suffix, e.g. /r/*.
+func matchStreamPath(pattern, recordPath string) bool {
+	if pattern == "" {
+		return true
+	}
+	if strings.HasSuffix(pattern, "*") && strings.HasPrefix(recordPath, strings.TrimSuffix(pattern, "*")) {
+		return true
+	}
+
+	matched, _ := path.Match(pattern, recordPath)
+	return matched
+}
+
+// streamReadable checks if the access token of the request may read recordPath.
+func (a *Agent) streamReadable(r *http.Request, recordPath string) bool {
+	if len(a.accessTokens()) == 0 {
+		return true
+	}
+
+	accessTokenString, _, _ := libhttp.ParseBasicAuth(r.Header.Get("Authorization"))
+	_, err := a.Authorize(accessTokenString, "GET", recordPath)
+	return err == nil
+}
+
+// serveStream pushes records matching pattern as Server-Sent Events until the client disconnects or the agent shuts down.
+func (a *Agent) serveStream(w http.ResponseWriter, r *http.Request, pattern string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		libhttp.HandleErrorJson(w, errors.New("Streaming is not supported"))
+		return
+	}
+
+	subscriber := a.streams.Subscribe(pattern)
+	defer a.streams.Unsubscribe(subscriber)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(200)
+
+	// Confirms the subscription, clients do not have to wait for the first record.
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(StreamKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		var err error
+
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-a.streamsCtx.Done():
+			return
+
+		case <-keepAlive.C:
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+
+		case event := <-subscriber.events:
+			if !a.streamReadable(r, event.Path) {
+				continue
+			}
+
+			data, redactErr := resourced_config.RedactJSON(event.Data)
+			if redactErr != nil {
+				continue
+			}
+			_, err = fmt.Fprintf(w, "id: %v\nevent: %v\ndata: %s\n\n", event.UnixNano, event.Path, data)
+		}
+
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// StreamGetHandler streams every new record as Server-Sent Events. ?path=/r/* filters records by path glob.
+func (a *Agent) StreamGetHandler() func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		pattern := r.URL.Query().Get("path")
+
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			libhttp.HandleErrorJsonWithStatusCode(w, fmt.Errorf("Invalid path pattern: %v", pattern), 400)
+			return
+		}
+
+		a.serveStream(w, r, pattern)
+	}
+}
+
+// streamHandlerByPath returns a function that streams new records of a single path as Server-Sent Events.
+func (a *Agent) streamHandlerByPath(recordPath string) func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		a.serveStream(w, r, recordPath)
+	}
+}
+
+// MapStreamGetHandlers returns stream handlers of readers, writers, and executors, keyed by path + "/stream".
+func (a *Agent) MapStreamGetHandlers() map[string]func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	handlersMap := make(map[string]func(w http.ResponseWriter, r *http.Request, ps httprouter.Params))
+
+	allConfigs := a.configs()
+
+	for _, configs := range [][]resourced_config.Config{allConfigs.Readers, allConfigs.Writers, allConfigs.Executors} {
+		for _, config := range configs {
+			if config.Path != "" {
+				handlersMap[config.PathWithPrefix()+"/stream"] = a.streamHandlerByPath(config.PathWithPrefix())
+			}
+		}
+	}
+	return handlersMap
+}
@@ -0,0 +1,148 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamByPath(t *testing.T) {
+	agent := createAgentForTest(t)
+
+	server := httptest.NewServer(agent)
+	defer server.Close()
+
+	config := agent.Configs.Readers[0]
+
+	resp, err := http.Get(server.URL + config.PathWithPrefix() + "/stream")
+	if err != nil {
+		t.Fatalf("Stream request should work. Error: %v", err)
+	}
+
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Stream should be served as text/event-stream. Content-Type: %v", resp.Header.Get("Content-Type"))
+	}
+
+	reader := bufio.NewReader(resp.Body)
+
+	// Wait until subscribed.
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, ": connected") {
+		t.Fatalf("Stream should confirm the subscription. Line: %v, Error: %v", line, err)
+	}
+
+	// Records of other paths are filtered out.
+	agent.saveRun(agent.Configs.Readers[1], []byte(`{"Other": true}`), nil)
+	agent.saveRun(config, []byte(`{"Streamed": true}`), nil)
+
+	var data string
+	for data == "" {
+		line, err = reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Reading stream should work. Error: %v", err)
+		}
+		if strings.HasPrefix(line, "event: ") && strings.TrimSpace(line) != "event: "+config.PathWithPrefix() {
+			t.Fatalf("Stream should only contain records of %v. Line: %v", config.PathWithPrefix(), line)
+		}
+		if strings.HasPrefix(line, "data: ") {
+			data = line
+		}
+	}
+
+	if !strings.Contains(data, `"Streamed":true`) {
+		t.Fatalf("Stream should push the stored record. Data: %v", data)
+	}
+
+	resp.Body.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for agent.streams.Len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if agent.streams.Len() != 0 {
+		t.Fatalf("Disconnected stream client should be unsubscribed. Subscribers: %v", agent.streams.Len())
+	}
+}
+
+func TestStreamKeepAlive(t *testing.T) {
+	interval := StreamKeepAliveInterval
+	StreamKeepAliveInterval = 10 * time.Millisecond
+	defer func() { StreamKeepAliveInterval = interval }()
+
+	agent := createAgentForTest(t)
+
+	server := httptest.NewServer(agent)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/stream?path=/r/*")
+	if err != nil {
+		t.Fatalf("Stream request should work. Error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	reader.ReadString('\n')
+	reader.ReadString('\n')
+
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, ": keep-alive") {
+		t.Fatalf("Idle stream should receive keep-alives. Line: %v, Error: %v", line, err)
+	}
+
+	resp, err = http.Get(server.URL + "/stream?path=[")
+	if err != nil {
+		t.Fatalf("Stream request should work. Error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 400 {
+		t.Fatalf("Invalid path pattern should be rejected. Status: %v", resp.StatusCode)
+	}
+}
+
+func TestMatchStreamPath(t *testing.T) {
+	if !matchStreamPath("", "/r/cpu/info") {
+		t.Fatalf("Empty pattern should match every path.")
+	}
+	if !matchStreamPath("/r/*", "/r/cpu/info") {
+		t.Fatalf("Trailing * should match any suffix.")
+	}
+	if !matchStreamPath("/r/*/info", "/r/cpu/info") {
+		t.Fatalf("Glob should match path.")
+	}
+	if matchStreamPath("/x/*", "/r/cpu/info") {
+		t.Fatalf("Pattern of another prefix should not match.")
+	}
+}
+
+func TestShutdownClosesStreams(t *testing.T) {
+	agent := createAgentForTest(t)
+
+	server := httptest.NewUnstartedServer(agent)
+	server.Config.RegisterOnShutdown(agent.CloseStreams)
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + agent.Configs.Readers[0].PathWithPrefix() + "/stream")
+	if err != nil {
+		t.Fatalf("Stream request should work. Error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, ": connected") {
+		t.Fatalf("Stream should confirm the subscription. Line: %v, Error: %v", line, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	err = server.Config.Shutdown(ctx)
+	if err != nil {
+		t.Fatalf("Shutdown should not wait for stream clients. Error: %v", err)
+	}
+}
@@ -10,7 +10,13 @@ import (
 )
 
 func (a *Agent) setTags() error {
-	a.Tags = make(map[string]string)
+	tags := make(map[string]string)
+
+	defer func() {
+		a.Lock()
+		a.Tags = tags
+		a.Unlock()
+	}()
 
 	configDir := os.Getenv("RESOURCED_CONFIG_DIR")
 	if configDir == "" {
@@ -39,7 +45,7 @@ func (a *Agent) setTags() error {
 			for _, tagKeyValue := range tagsPerLine {
 				keyValue := strings.Split(tagKeyValue, "=")
 				if len(keyValue) >= 2 {
-					a.Tags[keyValue[0]] = strings.Join(keyValue[1:], "=")
+					tags[keyValue[0]] = strings.Join(keyValue[1:], "=")
 				}
 			}
 		}
@@ -47,3 +53,11 @@ func (a *Agent) setTags() error {
 
 	return nil
 }
+
+// tags returns the current tags, they are replaced on reload.
+func (a *Agent) tags() map[string]string {
+	a.RLock()
+	defer a.RUnlock()
+
+	return a.Tags
+}
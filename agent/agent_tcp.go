@@ -23,7 +23,11 @@ func (a *Agent) NewTCPServer(config resourced_config.ITCPServer, name string) (n
 			logFields["LogReceiver.CertFile"] = config.GetCertFile()
 			logFields["LogReceiver.KeyFile"] = config.GetKeyFile()
 
-			cert, err := tls.LoadX509KeyPair(config.GetCertFile(), config.GetKeyFile())
+			if config.GetClientCAFile() != "" {
+				logFields["LogReceiver.ClientCAFile"] = config.GetClientCAFile()
+			}
+
+			tlsConfig, err := NewServerTLSConfig(config.GetCertFile(), config.GetKeyFile(), config.GetClientCAFile(), config.GetAllowedClients())
 			if err != nil {
 				logrus.WithFields(logFields).Fatal(err)
 				return nil, err
@@ -31,8 +35,6 @@ func (a *Agent) NewTCPServer(config resourced_config.ITCPServer, name string) (n
 
 			logrus.WithFields(logFields).Info("Running " + name + "+SSL server")
 
-			tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
-
 			return tls.Listen("tcp", config.GetAddr(), tlsConfig)
 
 		} else {
@@ -45,7 +47,35 @@ func (a *Agent) NewTCPServer(config resourced_config.ITCPServer, name string) (n
 	return nil, nil
 }
 
+// ServeTCP accepts connections on listener and hands them to handler until the listener is closed.
+// The listener is closed on Shutdown.
+func (a *Agent) ServeTCP(listener net.Listener, handler func(net.Conn)) {
+	a.Lock()
+	a.listeners = append(a.listeners, listener)
+	a.Unlock()
+
+	go func(listener net.Listener) {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if a.ctx.Err() != nil {
+					return
+				}
+				if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+					continue
+				}
+				return
+			}
+
+			go handler(conn)
+		}
+	}(listener)
+}
+
+// HandleGraphite stores a Graphite metric. With mutual TLS, the client identity of each metric is kept in GraphiteClientDB.
 func (a *Agent) HandleGraphite(conn net.Conn) {
+	clientIdentity := connClientIdentity(conn)
+
 	dataInBytes, err := ioutil.ReadAll(conn)
 	if err == nil {
 		dataInChunks := strings.Split(string(dataInBytes), " ")
@@ -67,6 +97,10 @@ func (a *Agent) HandleGraphite(conn net.Conn) {
 
 				if doSetValue {
 					a.GraphiteDB.Set(key, value)
+
+					if clientIdentity != "" {
+						a.GraphiteClientDB.Set(key, clientIdentity)
+					}
 				}
 			}
 		}
@@ -76,10 +110,18 @@ func (a *Agent) HandleGraphite(conn net.Conn) {
 	conn.Close()
 }
 
+// HandleLog stores a logline. With mutual TLS, the logline is prefixed with the client identity, e.g. [client:web-1].
 func (a *Agent) HandleLog(conn net.Conn) {
+	clientIdentity := connClientIdentity(conn)
+
 	dataInBytes, err := ioutil.ReadAll(conn)
 	if err == nil {
-		a.TCPLogDB.Append("Loglines", string(dataInBytes))
+		logline := string(dataInBytes)
+		if clientIdentity != "" {
+			logline = "[client:" + clientIdentity + "] " + logline
+		}
+
+		a.TCPLogDB.Append("Loglines", logline)
 	}
 
 	conn.Write([]byte(""))
@@ -1,13 +1,18 @@
 package agent
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	resourced_config "github.com/resourced/resourced/config"
 	_ "github.com/resourced/resourced/readers/docker"
@@ -16,7 +21,7 @@ import (
 func createAgentForTest(t *testing.T) *Agent {
 	agent := createAgentWithAccessTokensForTest(t)
 
-	agent.AccessTokens = make([]string, 0)
+	agent.AccessTokens = make([]AccessToken, 0)
 
 	return agent
 }
@@ -24,6 +29,9 @@ func createAgentForTest(t *testing.T) *Agent {
 func createAgentWithAccessTokensForTest(t *testing.T) *Agent {
 	os.Setenv("RESOURCED_CONFIG_DIR", os.ExpandEnv("$GOPATH/src/github.com/resourced/resourced/tests/resourced-configs"))
 
+	// Silences of a test must not leak into other tests.
+	os.Setenv("RESOURCED_STATE_DIR", t.TempDir())
+
 	agent, err := New()
 	if err != nil {
 		t.Fatalf("Initializing agent should work. Error: %v", err)
@@ -128,3 +136,251 @@ func TestInitGoStructWriter(t *testing.T) {
 		}
 	}
 }
+
+func TestShutdown(t *testing.T) {
+	agent := createAgentForTest(t)
+
+	config := agent.Configs.Readers[0]
+	config.Interval = "1h"
+	agent.RunForever(config)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listening on random port should work. Error: %v", err)
+	}
+	agent.ServeTCP(listener, agent.HandleLog)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = agent.Shutdown(ctx)
+	if err != nil {
+		t.Fatalf("Shutdown should finish before the deadline. Error: %v", err)
+	}
+
+	if len(agent.RunningConfigs()) != 0 {
+		t.Errorf("There should be no running configs after Shutdown. RunningConfigs: %v", agent.RunningConfigs())
+	}
+
+	_, err = net.Dial("tcp", listener.Addr().String())
+	if err == nil {
+		t.Errorf("Listener should be closed after Shutdown")
+	}
+}
+
+func TestHttpRouterHistory(t *testing.T) {
+	agent := createAgentForTest(t)
+
+	for i := 0; i < 3; i++ {
+		_, err := agent.Run(agent.Configs.Readers[0])
+		if err != nil {
+			t.Fatalf("Run should work. Error: %v", err)
+		}
+	}
+
+	router := agent.HttpRouter()
+
+	for url, expectedLength := range map[string]int{
+		"/r/cpu/info?from=15m":         3,
+		"/r/cpu/info?from=15m&limit=2": 2,
+		"/r/cpu/info?limit=1":          1,
+	} {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Errorf("Failed to create HTTP request. Error: %v", err)
+		}
+
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != 200 {
+			t.Fatalf("History request should work. URL: %v, Status: %v, Body: %s", url, resp.Code, resp.Body.Bytes())
+		}
+
+		var runs []map[string]interface{}
+		err = json.Unmarshal(resp.Body.Bytes(), &runs)
+		if err != nil {
+			t.Fatalf("History response should be JSON array. Error: %v", err)
+		}
+		if len(runs) != expectedLength {
+			t.Errorf("History response has incorrect length. URL: %v, Length: %v", url, len(runs))
+		}
+	}
+
+	for _, url := range []string{"/r/cpu/info?from=yesterday", "/r/cpu/info?limit=0", "/r/cpu/info?limit=-1"} {
+		req, _ := http.NewRequest("GET", url, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != 400 {
+			t.Errorf("Invalid from or limit should be rejected. URL: %v, Status: %v", url, resp.Code)
+		}
+	}
+}
+
+func TestHttpRouterExecutorStates(t *testing.T) {
+	agent := createAgentForTest(t)
+
+	agent.ExecutorStateDB.Transition("/uptime", true, true)
+
+	req, err := http.NewRequest("GET", "/x/states", nil)
+	if err != nil {
+		t.Errorf("Failed to create HTTP request. Error: %v", err)
+	}
+
+	resp := httptest.NewRecorder()
+	agent.HttpRouter().ServeHTTP(resp, req)
+
+	if resp.Code != 200 {
+		t.Fatalf("States request should work. Status: %v, Body: %s", resp.Code, resp.Body.Bytes())
+	}
+
+	var states map[string]map[string]interface{}
+	err = json.Unmarshal(resp.Body.Bytes(), &states)
+	if err != nil {
+		t.Fatalf("States response should be JSON object. Error: %v", err)
+	}
+	if states["/x/uptime"]["State"] != "FIRING" {
+		t.Errorf("/x/uptime should be FIRING. States: %v", states)
+	}
+}
+
+func TestHttpRouterRun(t *testing.T) {
+	agent := createAgentForTest(t)
+	router := agent.HttpRouter()
+
+	config := agent.Configs.Readers[0]
+	url := config.PathWithPrefix() + "/run"
+
+	req, _ := http.NewRequest("POST", url, nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != 200 {
+		t.Fatalf("Run request should work. URL: %v, Status: %v, Body: %s", url, resp.Code, resp.Body.Bytes())
+	}
+
+	var run map[string]interface{}
+	err := json.Unmarshal(resp.Body.Bytes(), &run)
+	if err != nil || run["Data"] == nil {
+		t.Fatalf("Run response should contain fresh data. Body: %s", resp.Body.Bytes())
+	}
+
+	stored, _ := agent.GetRunByPath(config.PathWithPrefix())
+	if string(stored) != resp.Body.String() {
+		t.Fatalf("Run should update ResultDB. Stored: %s", stored)
+	}
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != 200 {
+		t.Fatalf("Run right after a finished run should work. Status: %v, Body: %s", resp.Code, resp.Body.Bytes())
+	}
+
+	// Pretend the loop is running the same config.
+	agent.startInFlight(config.Key())
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != 409 {
+		t.Fatalf("Concurrent run should be rejected. Status: %v", resp.Code)
+	}
+}
+
+func TestHttpRouterRecordQuery(t *testing.T) {
+	agent := createAgentForTest(t)
+	router := agent.HttpRouter()
+
+	config := agent.Configs.Readers[0]
+	agent.saveRun(config, []byte(`{"1": {"Name": "init", "State": "S", "Cpu": 0.1}, "2": {"Name": "zombie", "State": "Z", "Cpu": 2.5}}`), nil)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", url, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	path := config.PathWithPrefix()
+
+	resp := get(path + `?select=Data["2"].Cpu`)
+	if resp.Code != 200 || resp.Body.String() != "2.5" {
+		t.Fatalf("select should render the selected value. Status: %v, Body: %s", resp.Code, resp.Body.Bytes())
+	}
+
+	resp = get(path + `?where=State%20==%20"Z"&fields=Name`)
+	if resp.Code != 200 {
+		t.Fatalf("where should work. Status: %v, Body: %s", resp.Code, resp.Body.Bytes())
+	}
+
+	var record map[string]interface{}
+	json.Unmarshal(resp.Body.Bytes(), &record)
+
+	data, _ := record["Data"].(map[string]interface{})
+	if len(data) != 1 || fmt.Sprint(data["2"]) != "map[Name:zombie]" || record["Path"] == nil {
+		t.Fatalf("where should filter Data of the record, fields should project each element. Body: %s", resp.Body.Bytes())
+	}
+
+	resp = get(path + `?exclude=Data,Host`)
+	record = nil
+	json.Unmarshal(resp.Body.Bytes(), &record)
+	if _, ok := record["Data"]; ok || record["Path"] == nil {
+		t.Fatalf("exclude should remove keys of the record. Body: %s", resp.Body.Bytes())
+	}
+
+	resp = get(`/r?select=Data["1"].Name`)
+	if resp.Code != 200 || resp.Body.String() != `["init"]` {
+		t.Fatalf("Readers query should skip records it does not apply to. Status: %v, Body: %s", resp.Code, resp.Body.Bytes())
+	}
+
+	for _, url := range []string{path + `?where=State%20==`, path + `?select=Data.missing`, `/?fields=Data.*`, `/r?select=Data.missing`, `/?select=Data.missing`} {
+		resp = get(url)
+		if resp.Code != 400 {
+			t.Fatalf("Invalid query should be rejected. URL: %v, Status: %v", url, resp.Code)
+		}
+	}
+}
+
+func TestHttpRouterEncodings(t *testing.T) {
+	agent := createAgentForTest(t)
+	router := agent.HttpRouter()
+
+	config := agent.Configs.Readers[0]
+	agent.saveRun(config, []byte(`{"1": {"Name": "init"}, "2": {"Name": "sh"}}`), nil)
+
+	get := func(url, accept string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", url, nil)
+		req.Header.Set("Accept", accept)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := get(config.PathWithPrefix(), "text/csv")
+	if resp.Code != 200 || resp.Header().Get("Content-Type") != "text/csv" || resp.Body.String() != "Key,Name\n1,init\n2,sh\n" {
+		t.Fatalf("Accept: text/csv should render Data as CSV. Status: %v, Body: %s", resp.Code, resp.Body.Bytes())
+	}
+
+	resp = get(config.PathWithPrefix()+"?format=text", "")
+	if resp.Code != 200 || !strings.Contains(resp.Body.String(), "Data.2.Name=sh\n") {
+		t.Fatalf("format=text should render flat key/value lines. Status: %v, Body: %s", resp.Code, resp.Body.Bytes())
+	}
+
+	resp = get("/r", "application/msgpack")
+	if resp.Code != 200 || resp.Header().Get("Content-Type") != "application/msgpack" {
+		t.Fatalf("Accept: application/msgpack should render msgpack. Status: %v, Body: %s", resp.Code, resp.Body.Bytes())
+	}
+
+	resp = get("/", "")
+	var payload map[string]interface{}
+	if resp.Code != 200 || json.Unmarshal(resp.Body.Bytes(), &payload) != nil || payload["Readers"] == nil {
+		t.Fatalf("JSON should be the default. Status: %v, Body: %s", resp.Code, resp.Body.Bytes())
+	}
+
+	resp = get("/r?format=xml", "")
+	if resp.Code != 406 {
+		t.Fatalf("Unsupported format should be rejected. Status: %v", resp.Code)
+	}
+}
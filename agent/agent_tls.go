@@ -0,0 +1,124 @@
+package agent
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+)
+
+// NewServerTLSConfig builds the TLS config of a server.
+// When clientCAFile is defined, clients must present a certificate signed by it,
+// and when allowedClients is not empty, the certificate CN or one of its SANs must be in allowedClients.
+// allowedClients without clientCAFile is an error, clients could not be verified.
+func NewServerTLSConfig(certFile, keyFile, clientCAFile string, allowedClients []string) (*tls.Config, error) {
+	if len(allowedClients) > 0 && clientCAFile == "" {
+		return nil, errors.New("AllowedClients requires ClientCAFile")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	if clientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	caPEM, err := ioutil.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("No certificates found in ClientCAFile: %v", clientCAFile)
+	}
+
+	tlsConfig.ClientCAs = clientCAs
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+
+	if len(allowedClients) > 0 {
+		tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+			for _, chain := range verifiedChains {
+				if len(chain) > 0 && isAllowedClient(chain[0], allowedClients) {
+					return nil
+				}
+			}
+			return errors.New("Client certificate is not in AllowedClients")
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// clientNames returns the CN and SANs of a client certificate.
+func clientNames(cert *x509.Certificate) []string {
+	names := make([]string, 0)
+
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+
+	return names
+}
+
+func isAllowedClient(cert *x509.Certificate, allowedClients []string) bool {
+	for _, name := range clientNames(cert) {
+		for _, allowed := range allowedClients {
+			if strings.TrimSpace(allowed) == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ClientIdentity returns the CN, or the first SAN, of the verified client certificate.
+// It is empty when the client did not present a verified certificate.
+func ClientIdentity(state *tls.ConnectionState) string {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ""
+	}
+
+	names := clientNames(state.VerifiedChains[0][0])
+	if len(names) == 0 {
+		return ""
+	}
+	return names[0]
+}
+
+// connClientIdentity completes the TLS handshake of conn and returns the verified client identity.
+func connClientIdentity(conn net.Conn) string {
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return ""
+	}
+
+	if tlsConn.Handshake() != nil {
+		return ""
+	}
+
+	state := tlsConn.ConnectionState()
+	return ClientIdentity(&state)
+}
+
+// NewHTTPSConfig builds the TLS config of the HTTPS server from GeneralConfig.HTTPS.
+func (a *Agent) NewHTTPSConfig() (*tls.Config, error) {
+	https := a.GeneralConfig.HTTPS
+	return NewServerTLSConfig(https.CertFile, https.KeyFile, https.ClientCAFile, https.AllowedClients)
+}
@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newCertForTest creates a certificate signed by parent, or a self-signed CA when parent is nil.
+func newCertForTest(t *testing.T, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Generating key should work. Error: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("Creating certificate should work. Error: %v", err)
+	}
+
+	cert, _ := x509.ParseCertificate(der)
+	keyDer, _ := x509.MarshalECPrivateKey(key)
+
+	return cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func TestMutualTLSGraphite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "resourced-tls")
+	if err != nil {
+		t.Fatalf("Creating temp dir should work. Error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca, caKey, caPEM, _ := newCertForTest(t, "test-ca", nil, nil)
+	_, _, serverPEM, serverKeyPEM := newCertForTest(t, "resourced", ca, caKey)
+	_, _, allowedPEM, allowedKeyPEM := newCertForTest(t, "web-1", ca, caKey)
+	_, _, deniedPEM, deniedKeyPEM := newCertForTest(t, "db-1", ca, caKey)
+
+	files := map[string][]byte{"ca.pem": caPEM, "server.pem": serverPEM, "server-key.pem": serverKeyPEM}
+	for name, content := range files {
+		ioutil.WriteFile(filepath.Join(dir, name), content, 0600)
+	}
+
+	_, err = NewServerTLSConfig(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem"), "", []string{"web-1"})
+	if err == nil {
+		t.Fatalf("AllowedClients without ClientCAFile should fail.")
+	}
+
+	tlsConfig, err := NewServerTLSConfig(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem"), filepath.Join(dir, "ca.pem"), []string{"web-1"})
+	if err != nil {
+		t.Fatalf("Building TLS config should work. Error: %v", err)
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	if err != nil {
+		t.Fatalf("Listening should work. Error: %v", err)
+	}
+
+	agent := createAgentForTest(t)
+	agent.ServeTCP(listener, agent.HandleGraphite)
+	defer agent.Stop()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AppendCertsFromPEM(caPEM)
+
+	send := func(certPEM, keyPEM []byte, payload string) error {
+		clientConfig := &tls.Config{RootCAs: rootCAs}
+
+		if certPEM != nil {
+			cert, err := tls.X509KeyPair(certPEM, keyPEM)
+			if err != nil {
+				t.Fatalf("Loading client certificate should work. Error: %v", err)
+			}
+			clientConfig.Certificates = []tls.Certificate{cert}
+		}
+
+		conn, err := tls.Dial("tcp", listener.Addr().String(), clientConfig)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		_, err = conn.Write([]byte(payload))
+		if err != nil {
+			return err
+		}
+		conn.CloseWrite()
+
+		// The server closes the connection once the metric is stored, or rejects the handshake.
+		_, err = ioutil.ReadAll(conn)
+		return err
+	}
+
+	err = send(allowedPEM, allowedKeyPEM, "servers.web.load 1.5")
+	if err != nil {
+		t.Fatalf("Allowed client should be able to send metrics. Error: %v", err)
+	}
+	if agent.GraphiteClientDB.Get("servers.web.load") != "web-1" {
+		t.Fatalf("Client identity should be recorded. Clients: %v", agent.GraphiteClientDB.All())
+	}
+
+	send(deniedPEM, deniedKeyPEM, "denied.load 1.5")
+	if _, ok := agent.GraphiteDB.All()["denied"]; ok {
+		t.Fatalf("Client outside AllowedClients should be rejected.")
+	}
+
+	send(nil, nil, "anonymous.load 1.5")
+	if _, ok := agent.GraphiteDB.All()["anonymous"]; ok {
+		t.Fatalf("Client without certificate should be rejected.")
+	}
+
+	if ClientIdentity(nil) != "" {
+		t.Fatalf("ClientIdentity without TLS should be empty.")
+	}
+}
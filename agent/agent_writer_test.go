@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"context"
 	resourced_config "github.com/resourced/resourced/config"
 	"os"
 	"strings"
@@ -28,7 +29,7 @@ func TestRunGoStructWriterWithJsonFlattener(t *testing.T) {
 			config := createConfigForAgentWriterTest(t)
 			config.GoStructFields["JsonProcessor"] = os.ExpandEnv("$GOPATH/src/github.com/resourced/resourced/tests/script-writer/json-flattener.py")
 
-			writerData, err := agent.runGoStructWriter(config)
+			writerData, err := agent.runGoStructWriter(context.Background(), config)
 			if err != nil {
 				t.Fatalf("runGoStructWriter should not fail. Error: %v", err)
 			}
@@ -56,7 +57,7 @@ func TestRunGoStructWriterWithInsightsDuFormatter(t *testing.T) {
 			config := createConfigForAgentWriterTest(t)
 			config.GoStructFields["JsonProcessor"] = os.ExpandEnv("$GOPATH/src/github.com/resourced/resourced/tests/script-writer/insights/du-formatter.py")
 
-			writerData, err := agent.runGoStructWriter(config)
+			writerData, err := agent.runGoStructWriter(context.Background(), config)
 			if err != nil {
 				t.Fatalf("runGoStructWriter should not fail. Error: %v", err)
 			}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/resourced/resourced/agent"
+	resourced_config "github.com/resourced/resourced/config"
+	"github.com/resourced/resourced/executors"
+	"github.com/resourced/resourced/loggers"
+	"github.com/resourced/resourced/readers"
+	"github.com/resourced/resourced/writers"
+)
+
+// commands are subcommands of resourced, they return the exit status.
+var commands = map[string]func(args []string) int{
+	"check-config":   runCheckConfig,
+	"run-once":       runRunOnce,
+	"list-gostructs": runListGoStructs,
+}
+
+// commandUsages are shown by resourced help and -h of each command.
+var commandUsages = map[string]string{
+	"check-config":   "check-config [config-dir]\n\tLoad general.toml and every config, and report all errors. config-dir defaults to RESOURCED_CONFIG_DIR.",
+	"run-once":       "run-once <file.toml>\n\tRun a single reader, writer, or executor config and print its JSON.",
+	"list-gostructs": "list-gostructs\n\tDescribe every registered reader, writer, executor, and logger, and its GoStructFields.",
+}
+
+// runCommand runs the command called name and returns the exit status.
+func runCommand(name string, args []string) int {
+	run, ok := commands[name]
+	if !ok {
+		if name != "help" && name != "-h" && name != "--help" {
+			fmt.Fprintf(os.Stderr, "Unknown command: %v\n\n", name)
+		}
+		printUsage()
+		return 2
+	}
+
+	return run(args)
+}
+
+func printUsage() {
+	names := make([]string, 0, len(commandUsages))
+	for name := range commandUsages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "Usage: resourced [command]")
+	fmt.Fprintln(os.Stderr, "\nWithout command, resourced runs the agent. Commands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %v\n", commandUsages[name])
+	}
+}
+
+// newFlagSet parses args of a command, printing its usage on -h.
+func newFlagSet(name string, args []string) (*flag.FlagSet, error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: resourced %v\n", commandUsages[name])
+	}
+	return flags, flags.Parse(args)
+}
+
+func runCheckConfig(args []string) int {
+	flags, err := newFlagSet("check-config", args)
+	if err != nil {
+		return 2
+	}
+
+	configDir := flags.Arg(0)
+	if configDir == "" {
+		configDir = os.Getenv("RESOURCED_CONFIG_DIR")
+	}
+	if configDir == "" {
+		fmt.Fprintln(os.Stderr, "RESOURCED_CONFIG_DIR is required")
+		return 2
+	}
+
+	configs, problems := agent.CheckConfigDir(configDir)
+
+	for _, problem := range problems {
+		fmt.Fprintln(os.Stderr, problem)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%v problems found\n", len(problems))
+		return 1
+	}
+
+	fmt.Printf("%v configs OK\n", len(configs.All()))
+	return 0
+}
+
+func runRunOnce(args []string) int {
+	flags, err := newFlagSet("run-once", args)
+	if err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	fullpath, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	config, err := agent.ConfigFromFile(fullpath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// The config file lives in readers/, writers/, or executors/ of the config directory.
+	if os.Getenv("RESOURCED_CONFIG_DIR") == "" {
+		os.Setenv("RESOURCED_CONFIG_DIR", filepath.Dir(filepath.Dir(fullpath)))
+	}
+
+	a, err := agent.NewInMemory()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	output, err := a.RunOnce(config)
+	if output != nil {
+		redacted, redactErr := resourced_config.RedactJSON(output)
+		if redactErr != nil {
+			fmt.Fprintln(os.Stderr, redactErr)
+			return 1
+		}
+		fmt.Println(string(redacted))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func runListGoStructs(args []string) int {
+	_, err := newFlagSet("list-gostructs", args)
+	if err != nil {
+		return 2
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	list := func(kind string, names []string, description func(string) string, newGoStruct func(string) (interface{}, error)) {
+		fmt.Fprintf(w, "%v:\n", kind)
+
+		for _, name := range names {
+			fmt.Fprintf(w, "  %v  %v\n", name, description(name))
+
+			goStruct, err := newGoStruct(name)
+			if err != nil {
+				fmt.Fprintf(w, "    Error: %v\n", err)
+				continue
+			}
+
+			for _, field := range resourced_config.GoStructFieldsOf(goStruct) {
+				status := ""
+				if field.Required {
+					status = "required"
+				} else if field.Default != "" {
+					status = fmt.Sprintf("default %q", field.Default)
+				}
+
+				line := fmt.Sprintf("    %v\t%v", field.Name, field.Type)
+				if status != "" || field.Description != "" {
+					line += "\t" + status
+				}
+				if field.Description != "" {
+					line += "\t" + field.Description
+				}
+
+				fmt.Fprintln(w, line)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	list("Readers", readers.Names(), readers.Description, func(name string) (interface{}, error) { return readers.NewGoStruct(name) })
+	list("Writers", writers.Names(), writers.Description, func(name string) (interface{}, error) { return writers.NewGoStruct(name) })
+	list("Executors", executors.Names(), executors.Description, func(name string) (interface{}, error) { return executors.NewGoStruct(name) })
+	list("Loggers", loggers.Names(), loggers.Description, func(name string) (interface{}, error) { return loggers.NewGoStruct(name) })
+
+	w.Flush()
+	return 0
+}
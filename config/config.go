@@ -2,16 +2,20 @@
 package config
 
 import (
+	"io"
 	"io/ioutil"
+	"os"
 	"path"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/Sirupsen/logrus"
 
 	"github.com/resourced/resourced/host"
 	"github.com/resourced/resourced/libstring"
+	"github.com/resourced/resourced/libtime"
 )
 
 // NewConfigs creates Configs struct given configDir.
@@ -21,6 +25,7 @@ func NewConfigs(configDir string) (*Configs, error) {
 	configs.Writers = make([]Config, 0)
 	configs.Executors = make([]Config, 0)
 	configs.Loggers = make([]Config, 0)
+	configs.FailedFilePaths = make([]string, 0)
 
 	var err error
 
@@ -33,9 +38,21 @@ func NewConfigs(configDir string) (*Configs, error) {
 
 		if err == nil {
 			for _, f := range configFiles {
+				if f.IsDir() {
+					continue
+				}
+
 				fullpath := path.Join(configDir, configKindPlural, f.Name())
 
 				conf, err := NewConfig(fullpath, configKind)
+				if err != nil {
+					logrus.WithFields(logrus.Fields{
+						"Error":    err.Error(),
+						"FilePath": fullpath,
+					}).Error("Failed to load config")
+
+					configs.FailedFilePaths = append(configs.FailedFilePaths, fullpath)
+				}
 				if err == nil {
 					if configKind == "reader" {
 						configs.Readers = append(configs.Readers, conf)
@@ -63,20 +80,96 @@ type Configs struct {
 	Writers   []Config
 	Executors []Config
 	Loggers   []Config
+
+	// FailedFilePaths are config files that could not be loaded, e.g. because of invalid TOML.
+	FailedFilePaths []string
+}
+
+// All returns readers, writers, executors, and loggers configs in one slice.
+func (cs *Configs) All() []Config {
+	all := make([]Config, 0, len(cs.Readers)+len(cs.Writers)+len(cs.Executors)+len(cs.Loggers))
+	all = append(all, cs.Readers...)
+	all = append(all, cs.Writers...)
+	all = append(all, cs.Executors...)
+	all = append(all, cs.Loggers...)
+
+	return all
+}
+
+// WithConfig returns a copy of cs with config added, or replacing the config of the same Key.
+func (cs *Configs) WithConfig(config Config) *Configs {
+	copied := cs.WithoutConfig(config.Key())
+
+	switch config.Kind {
+	case "reader":
+		copied.Readers = append(copied.Readers, config)
+	case "writer":
+		copied.Writers = append(copied.Writers, config)
+	case "executor":
+		copied.Executors = append(copied.Executors, config)
+	case "logger":
+		copied.Loggers = append(copied.Loggers, config)
+	}
+
+	return copied
+}
+
+// WithoutConfig returns a copy of cs without the config of key.
+func (cs *Configs) WithoutConfig(key string) *Configs {
+	without := func(configs []Config) []Config {
+		kept := make([]Config, 0, len(configs))
+		for _, config := range configs {
+			if config.Key() != key {
+				kept = append(kept, config)
+			}
+		}
+		return kept
+	}
+
+	return &Configs{
+		Readers:         without(cs.Readers),
+		Writers:         without(cs.Writers),
+		Executors:       without(cs.Executors),
+		Loggers:         without(cs.Loggers),
+		FailedFilePaths: cs.FailedFilePaths,
+	}
 }
 
 // NewConfig creates Config struct given fullpath and kind.
+// ${env:VAR}, ${file:/path}, and ${env:VAR:-fallback} references in string fields are resolved, see interpolate.
 func NewConfig(fullpath, kind string) (Config, error) {
 	fullpath = libstring.ExpandTildeAndEnv(fullpath)
 
-	var config Config
-	_, err := toml.DecodeFile(fullpath, &config)
+	content, err := ioutil.ReadFile(fullpath)
+	if err != nil {
+		return Config{Kind: kind, FilePath: fullpath, Interval: "1m"}, err
+	}
+
+	decode := func() (Config, error) {
+		var config Config
+		_, err := toml.Decode(string(content), &config)
+
+		if config.Interval == "" {
+			config.Interval = "1m"
+		}
+
+		config.Kind = kind
+		config.FilePath = fullpath
+
+		return config, err
+	}
 
-	if config.Interval == "" {
-		config.Interval = "1m"
+	config, err := decode()
+	if err != nil {
+		return config, err
 	}
 
-	config.Kind = kind
+	if referenceRegexp.Match(content) {
+		raw, _ := decode()
+		config.raw = &raw
+
+		err = interpolate(&config)
+	}
 
 	return config, err
 }
@@ -86,24 +179,68 @@ func NewConfig(fullpath, kind string) (Config, error) {
 // Writer config defines how to export the JSON data to a particular destination. E.g. Facts/graphing database.
 type Config struct {
 	GoStruct       string
-	GoStructFields map[string]interface{}
+	GoStructFields map[string]interface{} `toml:",omitempty"`
 	Path           string
-	Interval       string
-	Host           *host.Host
+
+	// Interval is a duration, e.g. "1m", or a cron expression, e.g. "*/5 * * * *" or "@hourly".
+	Interval string
+
+	// Align runs a duration Interval at its multiples on the wall clock, e.g. "10m" runs at :00, :10, :20...
+	Align bool `toml:",omitempty"`
+
+	// Jitter delays each run by a random duration up to Jitter, e.g. "30s".
+	Jitter string `toml:",omitempty"`
+
+	// Splay delays every run by a fixed duration up to Splay, picked from the hostname and Path,
+	// so agents of a fleet started at once do not run at the same second.
+	Splay string `toml:",omitempty"`
+
+	// Timeout stops waiting for a run after this duration, e.g. "30s", and saves a timeout error instead of its data.
+	// Defaults to Runs.Timeout of general.toml, "0s" means no timeout.
+	Timeout string `toml:",omitempty"`
+
+	Host *host.Host `toml:"-"`
 
 	// There are 4 kinds: reader, writer, executor, and log
-	Kind string
+	Kind string `toml:"-"`
+
+	// FilePath is the TOML file this config was loaded from.
+	FilePath string `toml:"-"`
 
 	// Writer specific fields
 	// ReaderPaths defines input data endpoints for a Writer.
-	ReaderPaths []string
+	ReaderPaths []string `toml:",omitempty"`
 
 	// Executor specific fields
-	LowThreshold               int64
-	HighThreshold              int64
-	Conditions                 string
-	ResourcedMasterURL         string
-	ResourcedMasterAccessToken string
+	LowThreshold               int64  `toml:",omitzero"`
+	HighThreshold              int64  `toml:",omitzero"`
+	Conditions                 string `toml:",omitempty"`
+	ResourcedMasterURL         string `toml:",omitempty"`
+	ResourcedMasterAccessToken string `toml:",omitempty"`
+
+	// raw is the config as written in its file, before references are resolved.
+	raw *Config
+}
+
+// Uninterpolated returns the config as written in its file, with ${env:...} and ${file:...} references instead of their values.
+// It is what the config API shows, so secrets are never displayed, and never written back to files.
+func (c Config) Uninterpolated() Config {
+	if c.raw == nil {
+		return c
+	}
+	return *c.raw
+}
+
+// Scheduler returns the schedule of runs defined by Interval, Align, Jitter, and Splay.
+func (c *Config) Scheduler() (*libtime.Scheduler, error) {
+	hostname, _ := os.Hostname()
+
+	return libtime.NewScheduler(c.Interval, c.Align, c.Jitter, c.Splay, hostname+c.PathWithPrefix())
+}
+
+// EncodeTOML writes config in the format read by NewConfig. Kind and FilePath are not written, they come from the file location.
+func (c Config) EncodeTOML(w io.Writer) error {
+	return toml.NewEncoder(w).Encode(c)
 }
 
 // CommonJsonData returns common information for every reader/writer/executor JSON interpretation.
@@ -124,6 +261,14 @@ func (c *Config) CommonJsonData() map[string]interface{} {
 	return record
 }
 
+// Key uniquely identifies a config. It is the TOML file path when the config was loaded from disk.
+func (c *Config) Key() string {
+	if c.FilePath != "" {
+		return c.FilePath
+	}
+	return c.Kind + ":" + c.PathWithPrefix()
+}
+
 // PathWithPrefix prepends the short version of config.Kind to path.
 func (c *Config) PathWithPrefix() string {
 	if c.Kind == "reader" {
@@ -163,11 +308,28 @@ func NewGeneralConfig(configDir string) (GeneralConfig, error) {
 
 	var config GeneralConfig
 	_, err := toml.DecodeFile(fullpath, &config)
+	if err == nil {
+		err = interpolate(&config)
+	}
 
 	if config.LogLevel == "" {
 		config.LogLevel = "info"
 	}
 
+	if config.History.Count == 0 {
+		config.History.Count = 60
+	}
+	if config.History.Duration == "" {
+		config.History.Duration = "15m"
+	}
+
+	if config.Runs.MaxConcurrency <= 0 {
+		config.Runs.MaxConcurrency = 10
+	}
+	if config.Runs.Timeout == "" {
+		config.Runs.Timeout = "1m"
+	}
+
 	config.Graphite.BlacklistCompiled = make([]*regexp.Regexp, 0)
 	for _, reg := range config.Graphite.Blacklist {
 		regCompiled, err := regexp.Compile(reg)
@@ -183,12 +345,20 @@ type ITCPServer interface {
 	GetAddr() string
 	GetCertFile() string
 	GetKeyFile() string
+	GetClientCAFile() string
+	GetAllowedClients() []string
 }
 
 type TCPConfig struct {
 	Addr     string
 	CertFile string
 	KeyFile  string
+
+	// ClientCAFile: when defined, clients must present a certificate signed by this CA.
+	ClientCAFile string
+
+	// AllowedClients: optional allow-list of client certificate CN or SAN values.
+	AllowedClients []string
 }
 
 func (c TCPConfig) GetAddr() string {
@@ -203,6 +373,14 @@ func (c TCPConfig) GetKeyFile() string {
 	return c.KeyFile
 }
 
+func (c TCPConfig) GetClientCAFile() string {
+	return c.ClientCAFile
+}
+
+func (c TCPConfig) GetAllowedClients() []string {
+	return c.AllowedClients
+}
+
 type GraphiteConfig struct {
 	TCPConfig
 	StatsInterval     string
@@ -216,6 +394,24 @@ type LogReceiverConfig struct {
 	AutoPruneLength       int64
 }
 
+// HistoryConfig defines how many samples are kept per reader path.
+type HistoryConfig struct {
+	// Count is the maximum number of samples per reader path.
+	Count int
+
+	// Duration is the maximum age of a sample.
+	Duration string
+}
+
+// RunsConfig bounds the runs of readers, writers, and executors.
+type RunsConfig struct {
+	// MaxConcurrency is the maximum number of runs at the same time. Other runs wait for their turn.
+	MaxConcurrency int
+
+	// Timeout is the default Timeout of configs.
+	Timeout string
+}
+
 func (l LogReceiverConfig) GetAutoPruneLength() int64 {
 	return l.AutoPruneLength
 }
@@ -226,14 +422,26 @@ type GeneralConfig struct {
 	LogLevel string
 	TTL      int
 
+	// DbPath is the directory of on-disk results storage.
+	// When empty, results are kept in-memory and lost on restart.
+	DbPath string
+
 	HTTPS struct {
 		CertFile string
 		KeyFile  string
+
+		// ClientCAFile: when defined, clients must present a certificate signed by this CA.
+		ClientCAFile string
+
+		// AllowedClients: optional allow-list of client certificate CN or SAN values.
+		AllowedClients []string
 	}
 	ResourcedMaster struct {
 		URL         string
 		AccessToken string
 	}
+	History     HistoryConfig
+	Runs        RunsConfig
 	Graphite    GraphiteConfig
 	LogReceiver LogReceiverConfig
 }
@@ -0,0 +1,420 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// FieldsError lists every problem found by DecodeGoStructFields.
+type FieldsError struct {
+	Problems []string
+}
+
+func (e *FieldsError) Error() string {
+	return "GoStructFields are invalid: " + strings.Join(e.Problems, "; ")
+}
+
+// DecodeGoStructFields copies fields into the exported fields of target, a pointer to struct. Fields of embedded structs are included.
+//
+// Values are converted to the field type:
+//   - Any integer, or integral float, into integer fields. Any number into float fields.
+//   - Strings such as "30s" into time.Duration fields.
+//   - Lists into slices, tables into maps and nested structs, converting every element.
+//
+// Fields tagged `default:"..."` get the default when they are not given. Defaults of slice fields are comma separated.
+// Fields tagged `required:"true"` must be given.
+//
+// Unknown fields, missing required fields, and values that cannot be converted are reported together in *FieldsError.
+func DecodeGoStructFields(fields map[string]interface{}, target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Cannot decode GoStructFields into %T, it must be a pointer to struct.", target)
+	}
+
+	problems := make([]string, 0)
+	decodeStruct(fields, v.Elem(), "", &problems)
+
+	if len(problems) > 0 {
+		return &FieldsError{Problems: problems}
+	}
+	return nil
+}
+
+func decodeStruct(fields map[string]interface{}, v reflect.Value, prefix string, problems *[]string) {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		structField, ok := v.Type().FieldByName(key)
+		if !ok || structField.PkgPath != "" {
+			*problems = append(*problems, fmt.Sprintf("unknown field %v%v", prefix, key))
+			continue
+		}
+
+		converted, err := convertField(fields[key], structField.Type, prefix+key+".", problems)
+		if err != nil {
+			*problems = append(*problems, fmt.Sprintf("%v%v %v", prefix, key, err))
+			continue
+		}
+		if converted.IsValid() {
+			v.FieldByIndex(structField.Index).Set(converted)
+		}
+	}
+
+	applyTags(fields, v, prefix, problems)
+}
+
+// applyTags sets defaults and checks required fields that are missing from fields.
+func applyTags(fields map[string]interface{}, v reflect.Value, prefix string, problems *[]string) {
+	for i := 0; i < v.NumField(); i++ {
+		structField := v.Type().Field(i)
+
+		// Exported fields of embedded structs are promoted, even when the embedded type itself is not exported.
+		if structField.Anonymous && structField.Type.Kind() == reflect.Struct {
+			applyTags(fields, v.Field(i), prefix, problems)
+			continue
+		}
+
+		if structField.PkgPath != "" {
+			continue
+		}
+
+		if _, given := fields[structField.Name]; given {
+			continue
+		}
+
+		if structField.Tag.Get("required") == "true" {
+			*problems = append(*problems, fmt.Sprintf("%v%v is required", prefix, structField.Name))
+			continue
+		}
+
+		if defaultValue, ok := structField.Tag.Lookup("default"); ok {
+			converted, err := convertDefault(defaultValue, structField.Type)
+			if err != nil {
+				*problems = append(*problems, fmt.Sprintf("%v%v has invalid default %q: %v", prefix, structField.Name, defaultValue, err))
+				continue
+			}
+			v.Field(i).Set(converted)
+		}
+	}
+}
+
+// convertField converts a decoded TOML or JSON value into t.
+// Problems of nested struct fields are appended to problems instead of being returned.
+func convertField(value interface{}, t reflect.Type, prefix string, problems *[]string) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(t), nil
+	}
+
+	if t == durationType {
+		s, ok := value.(string)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("must be a duration such as \"30s\", not %v", describe(value))
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("must be a duration such as \"30s\": %v", err)
+		}
+		return reflect.ValueOf(d), nil
+	}
+
+	given := reflect.ValueOf(value)
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if !given.Type().Implements(t) {
+			return reflect.Value{}, fmt.Errorf("must implement %v, not %v", t, describe(value))
+		}
+		result := reflect.New(t).Elem()
+		result.Set(given)
+		return result, nil
+
+	case reflect.String:
+		if given.Kind() != reflect.String {
+			return reflect.Value{}, fmt.Errorf("must be a string, not %v", describe(value))
+		}
+		return given.Convert(t), nil
+
+	case reflect.Bool:
+		if given.Kind() != reflect.Bool {
+			return reflect.Value{}, fmt.Errorf("must be a boolean, not %v", describe(value))
+		}
+		return given.Convert(t), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return convertNumber(value, t)
+
+	case reflect.Slice:
+		if given.Kind() != reflect.Slice && given.Kind() != reflect.Array {
+			return reflect.Value{}, fmt.Errorf("must be a list, not %v", describe(value))
+		}
+
+		result := reflect.MakeSlice(t, given.Len(), given.Len())
+		for i := 0; i < given.Len(); i++ {
+			element, err := convertField(given.Index(i).Interface(), t.Elem(), fmt.Sprintf("%v%v.", prefix, i), problems)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("element %v %v", i, err)
+			}
+			result.Index(i).Set(element)
+		}
+		return result, nil
+
+	case reflect.Map:
+		if given.Kind() != reflect.Map || given.Type().Key().Kind() != reflect.String || t.Key().Kind() != reflect.String {
+			return reflect.Value{}, fmt.Errorf("must be a table, not %v", describe(value))
+		}
+
+		result := reflect.MakeMap(t)
+		for _, key := range given.MapKeys() {
+			element, err := convertField(given.MapIndex(key).Interface(), t.Elem(), prefix+key.String()+".", problems)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("key %v %v", key.String(), err)
+			}
+			result.SetMapIndex(key.Convert(t.Key()), element)
+		}
+		return result, nil
+
+	case reflect.Struct:
+		table, ok := value.(map[string]interface{})
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("must be a table, not %v", describe(value))
+		}
+
+		result := reflect.New(t).Elem()
+		decodeStruct(table, result, prefix, problems)
+		return result, nil
+
+	case reflect.Ptr:
+		element, err := convertField(value, t.Elem(), prefix, problems)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		result := reflect.New(t.Elem())
+		result.Elem().Set(element)
+		return result, nil
+	}
+
+	if given.Type().AssignableTo(t) {
+		return given, nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot be set from %v", describe(value))
+}
+
+// convertNumber converts any integer, float, or json.Number into a numeric type, rejecting overflows and fractions.
+func convertNumber(value interface{}, t reflect.Type) (reflect.Value, error) {
+	var f float64
+	var i int64
+	isInt := false
+
+	switch n := value.(type) {
+	case json.Number:
+		if parsed, err := n.Int64(); err == nil {
+			i, isInt = parsed, true
+		} else if parsed, err := n.Float64(); err == nil {
+			f = parsed
+		} else {
+			return reflect.Value{}, fmt.Errorf("must be a number, not %v", n)
+		}
+
+	default:
+		given := reflect.ValueOf(value)
+
+		switch given.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i, isInt = given.Int(), true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if given.Uint() > math.MaxInt64 {
+				f = float64(given.Uint())
+			} else {
+				i, isInt = int64(given.Uint()), true
+			}
+		case reflect.Float32, reflect.Float64:
+			f = given.Float()
+		default:
+			return reflect.Value{}, fmt.Errorf("must be a number, not %v", describe(value))
+		}
+	}
+
+	result := reflect.New(t).Elem()
+
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if isInt {
+			f = float64(i)
+		}
+		if result.OverflowFloat(f) {
+			return reflect.Value{}, fmt.Errorf("%v overflows %v", f, t)
+		}
+		result.SetFloat(f)
+		return result, nil
+	}
+
+	if !isInt {
+		if f != math.Trunc(f) || math.Abs(f) >= 1<<63 {
+			return reflect.Value{}, fmt.Errorf("must be an integer, not %v", f)
+		}
+		i = int64(f)
+	}
+
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if i < 0 || result.OverflowUint(uint64(i)) {
+			return reflect.Value{}, fmt.Errorf("%v overflows %v", i, t)
+		}
+		result.SetUint(uint64(i))
+
+	default:
+		if result.OverflowInt(i) {
+			return reflect.Value{}, fmt.Errorf("%v overflows %v", i, t)
+		}
+		result.SetInt(i)
+	}
+
+	return result, nil
+}
+
+// convertDefault converts the default tag of a field into t.
+func convertDefault(defaultValue string, t reflect.Type) (reflect.Value, error) {
+	if t == durationType || t.Kind() == reflect.String {
+		return convertField(defaultValue, t, "", nil)
+	}
+
+	var value interface{}
+	var err error
+
+	switch t.Kind() {
+	case reflect.Bool:
+		value, err = strconv.ParseBool(defaultValue)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		value = json.Number(defaultValue)
+
+	case reflect.Slice:
+		result := reflect.MakeSlice(t, 0, 0)
+		for _, element := range strings.Split(defaultValue, ",") {
+			converted, err := convertDefault(strings.TrimSpace(element), t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result = reflect.Append(result, converted)
+		}
+		return result, nil
+
+	default:
+		return reflect.Value{}, fmt.Errorf("defaults are not supported for %v", t)
+	}
+
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return convertField(value, t, "", nil)
+}
+
+// describe names the type of a decoded value for error messages.
+func describe(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return fmt.Sprintf("string %q", value)
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "table"
+	}
+	return fmt.Sprintf("%T %v", value, value)
+}
+
+// GoStructField describes a field that can be set through GoStructFields.
+type GoStructField struct {
+	Name        string
+	Type        string
+	Default     string `json:",omitempty"`
+	Required    bool   `json:",omitempty"`
+	Description string `json:",omitempty"`
+	Example     string `json:",omitempty"`
+
+	reflectType reflect.Type
+}
+
+// GoStructFieldsOf lists the fields of goStruct, a pointer to struct, that are meant to be set through GoStructFields.
+// Data, the output of every GoStruct, and fields holding runtime state, e.g. pointers and interfaces, are left out.
+func GoStructFieldsOf(goStruct interface{}) []GoStructField {
+	t := reflect.TypeOf(goStruct)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	fields := make([]GoStructField, 0)
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+
+	collectGoStructFields(t, t, nil, &fields)
+	return fields
+}
+
+func collectGoStructFields(root, t reflect.Type, index []int, fields *[]GoStructField) {
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		fieldIndex := append(append([]int{}, index...), i)
+
+		if structField.Anonymous && structField.Type.Kind() == reflect.Struct {
+			collectGoStructFields(root, structField.Type, fieldIndex, fields)
+			continue
+		}
+
+		if structField.PkgPath != "" || structField.Name == "Data" || !isConfigurable(structField.Type) {
+			continue
+		}
+
+		// Skip fields shadowed by a field of the same name closer to root.
+		promoted, _ := root.FieldByName(structField.Name)
+		if !reflect.DeepEqual(promoted.Index, fieldIndex) {
+			continue
+		}
+
+		*fields = append(*fields, GoStructField{
+			Name:        structField.Name,
+			Type:        structField.Type.String(),
+			Default:     structField.Tag.Get("default"),
+			Required:    structField.Tag.Get("required") == "true",
+			Description: structField.Tag.Get("description"),
+			Example:     structField.Tag.Get("example"),
+			reflectType: structField.Type,
+		})
+	}
+}
+
+// isConfigurable tells if t can be written in TOML: scalars, durations, and lists, tables, or structs of them.
+func isConfigurable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8 && isConfigurable(t.Elem())
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && isConfigurable(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath == "" && !isConfigurable(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	case reflect.Ptr, reflect.Interface, reflect.Func, reflect.Chan, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128, reflect.Array:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+type testEmbeddedFields struct {
+	Url    string `required:"true"`
+	Method string `default:"POST"`
+}
+
+type testGoStructFields struct {
+	testEmbeddedFields
+	Port     int
+	Ratio    float64
+	Timeout  time.Duration `default:"5s"`
+	Globs    []string      `default:"*.log, *.gz"`
+	Ports    []uint16
+	Labels   map[string]string
+	Enabled  bool
+	Backend  struct{ Host string }
+	Anything interface{}
+	internal string
+}
+
+func TestDecodeGoStructFieldsFromTOML(t *testing.T) {
+	content := `
+Url = "http://localhost"
+Port = 8080
+Ratio = 1
+Timeout = "1m"
+Ports = [80, 443]
+Enabled = true
+Anything = ["one", "two"]
+
+[Backend]
+Host = "db-1"
+
+[Labels]
+env = "prod"
+`
+	fields := make(map[string]interface{})
+
+	_, err := toml.Decode(content, &fields)
+	if err != nil {
+		t.Fatalf("Decoding TOML should work. Error: %v", err)
+	}
+
+	s := &testGoStructFields{}
+
+	err = DecodeGoStructFields(fields, s)
+	if err != nil {
+		t.Fatalf("Decoding GoStructFields should work. Error: %v", err)
+	}
+
+	if s.Url != "http://localhost" || s.Port != 8080 || s.Ratio != 1 || !s.Enabled || s.Backend.Host != "db-1" {
+		t.Errorf("Fields are decoded incorrectly. Struct: %+v", s)
+	}
+	if s.Timeout != time.Minute {
+		t.Errorf("Duration should be parsed. Timeout: %v", s.Timeout)
+	}
+	if !reflect.DeepEqual(s.Ports, []uint16{80, 443}) {
+		t.Errorf("List should be converted element by element. Ports: %v", s.Ports)
+	}
+	if s.Labels["env"] != "prod" {
+		t.Errorf("Table should be converted to map. Labels: %v", s.Labels)
+	}
+	if len(s.Anything.([]interface{})) != 2 {
+		t.Errorf("interface{} field should be set as is. Anything: %v", s.Anything)
+	}
+
+	if s.Method != "POST" {
+		t.Errorf("Default of embedded field should be set. Method: %v", s.Method)
+	}
+	if !reflect.DeepEqual(s.Globs, []string{"*.log", "*.gz"}) {
+		t.Errorf("Default of list field should be split by comma. Globs: %v", s.Globs)
+	}
+}
+
+func TestDecodeGoStructFieldsFromJSON(t *testing.T) {
+	decoder := json.NewDecoder(strings.NewReader(`{"Url": "http://localhost", "Port": 8080, "Ratio": 0.5, "Timeout": "2s"}`))
+	decoder.UseNumber()
+
+	fields := make(map[string]interface{})
+
+	err := decoder.Decode(&fields)
+	if err != nil {
+		t.Fatalf("Decoding JSON should work. Error: %v", err)
+	}
+
+	s := &testGoStructFields{}
+
+	err = DecodeGoStructFields(fields, s)
+	if err != nil {
+		t.Fatalf("Decoding GoStructFields should work. Error: %v", err)
+	}
+
+	if s.Port != 8080 || s.Ratio != 0.5 || s.Timeout != 2*time.Second {
+		t.Errorf("Fields are decoded incorrectly. Struct: %+v", s)
+	}
+}
+
+func TestDecodeGoStructFieldsErrors(t *testing.T) {
+	fields := map[string]interface{}{
+		"Port":     "8080",
+		"Ratio":    true,
+		"Timeout":  "forever",
+		"Ports":    []interface{}{int64(80), int64(70000)},
+		"Backend":  map[string]interface{}{"Hostname": "db-1"},
+		"Unknown":  int64(1),
+		"internal": "hidden",
+		"Enabled":  1.5,
+	}
+
+	err := DecodeGoStructFields(fields, &testGoStructFields{})
+	if err == nil {
+		t.Fatalf("Decoding invalid GoStructFields should fail.")
+	}
+
+	fieldsErr, ok := err.(*FieldsError)
+	if !ok {
+		t.Fatalf("Error should be *FieldsError. Error: %T %v", err, err)
+	}
+
+	expected := []string{
+		"unknown field Backend.Hostname",
+		"Enabled must be a boolean",
+		"Port must be a number",
+		"Ports element 1 70000 overflows uint16",
+		"Ratio must be a number",
+		"Timeout must be a duration",
+		"unknown field Unknown",
+		"unknown field internal",
+		"Url is required",
+	}
+
+	if len(fieldsErr.Problems) != len(expected) {
+		t.Fatalf("Every problem should be reported. Problems: %v", fieldsErr.Problems)
+	}
+	for i, problem := range fieldsErr.Problems {
+		if !strings.HasPrefix(problem, expected[i]) {
+			t.Errorf("Problem %v should start with %q. Problem: %v", i, expected[i], problem)
+		}
+	}
+	if !strings.HasPrefix(err.Error(), "GoStructFields are invalid: unknown field Backend.Hostname; ") {
+		t.Errorf("Error message is incorrect. Error: %v", err)
+	}
+
+	err = DecodeGoStructFields(map[string]interface{}{"Url": "http://localhost", "Port": 1.5}, &testGoStructFields{})
+	if err == nil || !strings.Contains(err.Error(), "Port must be an integer, not 1.5") {
+		t.Errorf("Fractional number should not be decoded into int. Error: %v", err)
+	}
+}
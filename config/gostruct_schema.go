@@ -0,0 +1,114 @@
+package config
+
+import (
+	"reflect"
+)
+
+// durationPattern matches strings accepted by time.ParseDuration, e.g. "1m30s".
+const durationPattern = `^[-+]?([0-9]*(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+$`
+
+// Schema is the JSON Schema of a GoStruct, or of one of its fields.
+type Schema struct {
+	SchemaURI            string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Examples             []interface{}      `json:"examples,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// GoStructSchema describes the GoStructFields of goStruct, a pointer to struct, as JSON Schema.
+// Fields are listed by GoStructFieldsOf; description, default, and example tags are carried over.
+func GoStructSchema(name, description string, goStruct interface{}) *Schema {
+	schema := &Schema{
+		SchemaURI:            "http://json-schema.org/draft-07/schema#",
+		Title:                name,
+		Description:          description,
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+	}
+
+	for _, field := range GoStructFieldsOf(goStruct) {
+		fieldSchema := typeSchema(field.reflectType)
+		fieldSchema.Description = field.Description
+
+		if field.Default != "" {
+			fieldSchema.Default = tagValue(field.Default, field.reflectType)
+		}
+		if field.Example != "" {
+			fieldSchema.Examples = []interface{}{tagValue(field.Example, field.reflectType)}
+		}
+
+		schema.Properties[field.Name] = fieldSchema
+
+		if field.Required {
+			schema.Required = append(schema.Required, field.Name)
+		}
+	}
+
+	return schema
+}
+
+// typeSchema maps t to the JSON Schema type of its TOML value.
+func typeSchema(t reflect.Type) *Schema {
+	if t == durationType {
+		return &Schema{Type: "string", Pattern: durationPattern}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		minimum := float64(0)
+		return &Schema{Type: "integer", Minimum: &minimum}
+
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+
+	case reflect.String:
+		return &Schema{Type: "string"}
+
+	case reflect.Slice:
+		return &Schema{Type: "array", Items: typeSchema(t.Elem())}
+
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: typeSchema(t.Elem())}
+
+	case reflect.Struct:
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema), AdditionalProperties: false}
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath == "" {
+				schema.Properties[t.Field(i).Name] = typeSchema(t.Field(i).Type)
+			}
+		}
+		return schema
+	}
+
+	// interface{} accepts any value.
+	return &Schema{}
+}
+
+// tagValue converts a default or example tag into the JSON value of t.
+// Durations stay strings, as they are written in TOML. Values that cannot be converted are returned as is.
+func tagValue(tag string, t reflect.Type) interface{} {
+	if t == durationType {
+		return tag
+	}
+
+	value, err := convertDefault(tag, t)
+	if err != nil {
+		return tag
+	}
+	return value.Interface()
+}
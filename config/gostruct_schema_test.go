@@ -0,0 +1,67 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type testSchemaGoStruct struct {
+	Data     map[string]interface{}
+	Command  string   `required:"true" description:"Command to run." example:"uptime"`
+	Retries  uint     `default:"3"`
+	Globs    []string `example:"*.log, *.gz"`
+	Timeout  testSchemaTimeouts
+	internal string
+}
+
+type testSchemaTimeouts struct {
+	Connect string
+}
+
+func TestGoStructSchema(t *testing.T) {
+	schema := GoStructSchema("Test", "Runs tests.", &testSchemaGoStruct{})
+
+	if schema.Title != "Test" || schema.Description != "Runs tests." || schema.Type != "object" || schema.AdditionalProperties != false {
+		t.Errorf("GoStruct should be described as object. Schema: %+v", schema)
+	}
+	if !reflect.DeepEqual(schema.Required, []string{"Command"}) {
+		t.Errorf("Required fields should be listed. Required: %v", schema.Required)
+	}
+	if len(schema.Properties) != 4 {
+		t.Errorf("Data and unexported fields should be left out. Properties: %v", schema.Properties)
+	}
+
+	command := schema.Properties["Command"]
+	if command.Type != "string" || command.Description != "Command to run." || !reflect.DeepEqual(command.Examples, []interface{}{"uptime"}) {
+		t.Errorf("Command is described incorrectly. Schema: %+v", command)
+	}
+
+	retries := schema.Properties["Retries"]
+	if retries.Type != "integer" || *retries.Minimum != 0 || retries.Default != uint(3) {
+		t.Errorf("Retries is described incorrectly. Schema: %+v", retries)
+	}
+
+	globs := schema.Properties["Globs"]
+	if globs.Type != "array" || globs.Items.Type != "string" || !reflect.DeepEqual(globs.Examples, []interface{}{[]string{"*.log", "*.gz"}}) {
+		t.Errorf("Globs is described incorrectly. Schema: %+v", globs)
+	}
+
+	if schema.Properties["Timeout"].Properties["Connect"].Type != "string" {
+		t.Errorf("Struct field should be described as object. Schema: %+v", schema.Properties["Timeout"])
+	}
+
+	_, err := json.Marshal(schema)
+	if err != nil {
+		t.Errorf("Schema should be serialized to JSON. Error: %v", err)
+	}
+}
+
+func TestGoStructSchemaDuration(t *testing.T) {
+	schema := GoStructSchema("Test", "", &testGoStructFields{})
+
+	timeout := schema.Properties["Timeout"]
+	if timeout.Type != "string" || timeout.Pattern == "" || timeout.Default != "5s" {
+		t.Errorf("Duration should be described as string. Schema: %+v", timeout)
+	}
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/resourced/resourced/libstring"
+)
+
+// referenceRegexp matches ${env:VAR}, ${file:/path}, and both with a fallback, e.g. ${env:VAR:-fallback}.
+// $${ is an escaped, literal ${.
+var referenceRegexp = regexp.MustCompile(`\$\$\{|\$\{(env|file):([^}]*?)(?::-([^}]*))?\}`)
+
+// InterpolationError lists every reference of a config that cannot be resolved.
+type InterpolationError struct {
+	Problems []string
+}
+
+func (e *InterpolationError) Error() string {
+	return "References are unresolved: " + strings.Join(e.Problems, "; ")
+}
+
+// interpolateString resolves references in value.
+// Values read from environment variables or files are secrets, see Redact. Fallbacks are not, they are written in the config.
+func interpolateString(value string) (string, error) {
+	var err error
+
+	result := referenceRegexp.ReplaceAllStringFunc(value, func(reference string) string {
+		if reference == "$${" {
+			return "${"
+		}
+
+		match := referenceRegexp.FindStringSubmatchIndex(reference)
+		source, name := reference[match[2]:match[3]], reference[match[4]:match[5]]
+
+		hasFallback := match[6] != -1
+		fallback := ""
+		if hasFallback {
+			fallback = reference[match[6]:match[7]]
+		}
+
+		var resolved string
+		var found bool
+
+		switch source {
+		case "env":
+			resolved, found = os.LookupEnv(name)
+
+		case "file":
+			content, readErr := ioutil.ReadFile(libstring.ExpandTilde(name))
+			if readErr == nil {
+				resolved, found = strings.TrimRight(string(content), "\r\n"), true
+			} else if !hasFallback && err == nil {
+				err = fmt.Errorf("%v cannot be read. Error: %v", reference, readErr)
+			}
+		}
+
+		if found {
+			addSecret(resolved)
+			return resolved
+		}
+		if hasFallback {
+			return fallback
+		}
+		if err == nil {
+			err = fmt.Errorf("%v is not set", reference)
+		}
+		return reference
+	})
+
+	return result, err
+}
+
+// interpolateValue resolves references in every string of value: strings, lists, and tables decoded from TOML.
+// Lists and tables are updated in place.
+func interpolateValue(value interface{}, name string, problems *[]string) interface{} {
+	switch v := value.(type) {
+	case string:
+		resolved, err := interpolateString(v)
+		if err != nil {
+			*problems = append(*problems, fmt.Sprintf("%v: %v", name, err))
+		}
+		return resolved
+
+	case []interface{}:
+		for i, element := range v {
+			v[i] = interpolateValue(element, fmt.Sprintf("%v[%v]", name, i), problems)
+		}
+
+	case []map[string]interface{}:
+		for i, element := range v {
+			interpolateValue(element, fmt.Sprintf("%v[%v]", name, i), problems)
+		}
+
+	case map[string]interface{}:
+		for key, element := range v {
+			v[key] = interpolateValue(element, name+"."+key, problems)
+		}
+	}
+	return value
+}
+
+// interpolateFields resolves references in every exported string field of v, a struct, recursively.
+func interpolateFields(v reflect.Value, prefix string, problems *[]string) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if structField.PkgPath != "" {
+			continue
+		}
+
+		name := prefix + structField.Name
+		field := v.Field(i)
+
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(interpolateValue(field.String(), name, problems).(string))
+
+		case reflect.Slice:
+			if field.Type().Elem().Kind() == reflect.String {
+				for j := 0; j < field.Len(); j++ {
+					element := field.Index(j)
+					element.SetString(interpolateValue(element.String(), fmt.Sprintf("%v[%v]", name, j), problems).(string))
+				}
+			}
+
+		case reflect.Map:
+			if fields, ok := field.Interface().(map[string]interface{}); ok {
+				interpolateValue(fields, name, problems)
+			}
+
+		case reflect.Struct:
+			if structField.Anonymous {
+				interpolateFields(field, prefix, problems)
+			} else {
+				interpolateFields(field, name+".", problems)
+			}
+		}
+	}
+}
+
+// interpolate resolves ${env:...} and ${file:...} references in every string of config, a pointer to struct.
+func interpolate(config interface{}) error {
+	problems := make([]string, 0)
+
+	interpolateFields(reflect.ValueOf(config).Elem(), "", &problems)
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return &InterpolationError{Problems: problems}
+	}
+	return nil
+}
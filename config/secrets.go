@@ -0,0 +1,139 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/resourced/resourced/libstring"
+)
+
+// Redacted replaces secrets in HTTP responses and logs.
+const Redacted = "[REDACTED]"
+
+// MinSecretLength is the length below which resolved values are not redacted.
+// Short values like ports or booleans would otherwise be redacted from unrelated data.
+const MinSecretLength = 8
+
+// secrets are values resolved from ${env:...} and ${file:...} references.
+// Longer values come first, so a secret containing another one is redacted whole.
+var secrets = struct {
+	sync.RWMutex
+	values []string
+}{}
+
+// addSecret remembers value, so it is redacted from now on.
+func addSecret(value string) {
+	if len(value) < MinSecretLength {
+		return
+	}
+
+	secrets.Lock()
+	defer secrets.Unlock()
+
+	if libstring.StringInSlice(value, secrets.values) {
+		return
+	}
+	secrets.values = append(secrets.values, value)
+
+	sort.Slice(secrets.values, func(i, j int) bool {
+		return len(secrets.values[i]) > len(secrets.values[j])
+	})
+}
+
+// hasSecrets tells if any value must be redacted.
+func hasSecrets() bool {
+	secrets.RLock()
+	defer secrets.RUnlock()
+
+	return len(secrets.values) > 0
+}
+
+// Redact replaces every secret in s with [REDACTED].
+// s must be a plain value, e.g. an error message; use RedactJSON for serialized data.
+func Redact(s string) string {
+	secrets.RLock()
+	defer secrets.RUnlock()
+
+	for _, value := range secrets.values {
+		if strings.Contains(s, value) {
+			s = strings.Replace(s, value, Redacted, -1)
+		}
+	}
+	return s
+}
+
+// RedactJSON replaces secrets in the strings and keys of JSON data.
+// Numbers, booleans, and the JSON syntax are never changed.
+func RedactJSON(jsonData []byte) ([]byte, error) {
+	if !hasSecrets() {
+		return jsonData, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber()
+
+	var data interface{}
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(redactValue(data))
+}
+
+// redactValue redacts secrets from decoded JSON data.
+func redactValue(data interface{}) interface{} {
+	switch value := data.(type) {
+	case string:
+		return Redact(value)
+
+	case []interface{}:
+		for i := range value {
+			value[i] = redactValue(value[i])
+		}
+		return value
+
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(value))
+		for key, nested := range value {
+			redacted[Redact(key)] = redactValue(nested)
+		}
+		return redacted
+	}
+
+	return data
+}
+
+// RedactingFormatter redacts secrets from the message and fields of log entries before Formatter formats them.
+type RedactingFormatter struct {
+	Formatter logrus.Formatter
+}
+
+func (f *RedactingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if !hasSecrets() {
+		return f.Formatter.Format(entry)
+	}
+
+	redacted := *entry
+	redacted.Message = Redact(entry.Message)
+	redacted.Data = make(logrus.Fields, len(entry.Data))
+
+	for key, value := range entry.Data {
+		switch value := value.(type) {
+		case string:
+			redacted.Data[key] = Redact(value)
+		case error, fmt.Stringer:
+			redacted.Data[key] = Redact(fmt.Sprint(value))
+		default:
+			redacted.Data[key] = value
+		}
+	}
+
+	return f.Formatter.Format(&redacted)
+}
@@ -6,7 +6,8 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
-	"reflect"
+	"sort"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 
@@ -17,9 +18,11 @@ import (
 )
 
 var executorConstructors = make(map[string]func() IExecutor)
+var executorDescriptions = make(map[string]string)
 
 // Register makes any executor constructor available by name.
-func Register(name string, constructor func() IExecutor) {
+// description tells what the executor does, it is shown by GET /gostructs and resourced list-gostructs.
+func Register(name string, constructor func() IExecutor, description string) {
 	if constructor == nil {
 		panic("executor: Register executor constructor is nil")
 	}
@@ -27,6 +30,12 @@ func Register(name string, constructor func() IExecutor) {
 		panic("executor: Register called twice for executor constructor " + name)
 	}
 	executorConstructors[name] = constructor
+	executorDescriptions[name] = description
+}
+
+// Description returns the description of a registered executor constructor.
+func Description(name string) string {
+	return executorDescriptions[name]
 }
 
 // NewGoStruct instantiates IExecutor
@@ -39,6 +48,16 @@ func NewGoStruct(name string) (IExecutor, error) {
 	return constructor(), nil
 }
 
+// Names returns names of all registered executor constructors, sorted.
+func Names() []string {
+	names := make([]string, 0, len(executorConstructors))
+	for name := range executorConstructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // NewGoStructByConfig instantiates IExecutor given Config struct
 func NewGoStructByConfig(config resourced_config.Config) (IExecutor, error) {
 	executor, err := NewGoStruct(config.GoStruct)
@@ -49,16 +68,10 @@ func NewGoStructByConfig(config resourced_config.Config) (IExecutor, error) {
 	executor.SetPath(config.Path)
 	executor.SetInterval(config.Interval)
 
-	// Populate IExecutor fields dynamically
-	if len(config.GoStructFields) > 0 {
-		for structFieldInString, value := range config.GoStructFields {
-			goStructField := reflect.ValueOf(executor).Elem().FieldByName(structFieldInString)
-
-			if goStructField.IsValid() && goStructField.CanSet() {
-				valueOfValue := reflect.ValueOf(value)
-				goStructField.Set(valueOfValue)
-			}
-		}
+	// Populate IExecutor fields, converting values to the types of its fields
+	err = resourced_config.DecodeGoStructFields(config.GoStructFields, executor)
+	if err != nil {
+		return nil, err
 	}
 
 	return executor, nil
@@ -73,46 +86,74 @@ type IExecutor interface {
 	SetResourcedMasterAccessToken(string)
 	SetHostData(*host.Host)
 	SetCounterDB(*libmap.TSafeMapCounter)
+	SetStateDB(*StateDB)
+	SetSilenceDB(*SilenceDB)
 	Run() error
 	ToJson() ([]byte, error)
 	SetQueryParser(map[string][]byte)
+	SetHistory(queryparser.IHistory)
 	SetReadersDataInBytes(map[string][]byte)
 	SetTags(map[string]string)
 	IsConditionMet() bool
+	ConditionsError() error
 	LowThresholdExceeded() bool
 	HighThresholdExceeded() bool
 }
 
 type Base struct {
 	// Command: Shell command to execute.
-	Command string
+	Command string `description:"Command to run when Conditions are met." example:"uptime"`
 
 	// Path: ResourceD URL path. Example:
 	// /uptime -> http://localhost:55555/x/uptime
-	Path string
+	Path string `description:"Defaults to Path of the config."`
 
-	Interval string
+	Interval string `description:"Defaults to Interval of the config."`
 
 	// LowThreshold: minimum count of valid conditions
-	LowThreshold int64
+	LowThreshold int64 `description:"Minimum count of consecutive met Conditions before firing."`
 
 	// HighThreshold: maximum count of valid conditions
-	HighThreshold int64
+	HighThreshold int64 `description:"Maximum count of consecutive met Conditions to keep firing."`
 
 	// Conditions for when executor should run.
-	Conditions string
+	Conditions string `description:"Expression over readers data." example:"/r/load-avg.LoadAvg1m > 2"`
+
+	// Cooldown: minimum duration between two actions, e.g. "10m".
+	Cooldown string `description:"Minimum duration between two actions." example:"10m"`
+
+	// RepeatInterval: duration between actions while FIRING, e.g. "1h".
+	// When empty, actions are repeated on every Interval until HighThreshold is exceeded.
+	RepeatInterval string `description:"Duration between actions while FIRING." example:"1h"`
 
 	// Host data
 	Host *host.Host
 
-	ResourcedMasterURL         string
-	ResourcedMasterAccessToken string
+	ResourcedMasterURL         string `description:"Defaults to ResourcedMaster.URL of general.toml."`
+	ResourcedMasterAccessToken string `description:"Defaults to ResourcedMaster.AccessToken of general.toml."`
 
 	ReadersDataBytes map[string][]byte
 
 	qp *queryparser.QueryParser
 
+	// history of readers data, used by rate() and delta() in Conditions.
+	history queryparser.IHistory
+
+	// conditionsErr: Parse or evaluation error of the last IsConditionMet call.
+	conditionsErr error
+
 	counterDB *libmap.TSafeMapCounter
+
+	stateDB      *StateDB
+	state        State
+	stateChanged bool
+
+	silenceDB *SilenceDB
+	tags      map[string]string
+
+	// fire and resolve are decided by CheckState.
+	fire    bool
+	resolve bool
 }
 
 func (b *Base) SetPath(path string) {
@@ -129,12 +170,30 @@ func (b *Base) SetInterval(interval string) {
 
 func (b *Base) SetQueryParser(readersJsonBytes map[string][]byte) {
 	b.qp = queryparser.New(readersJsonBytes, nil)
+	b.qp.SetHistory(b.history)
+}
+
+// SetHistory assigns readers history to qp (QueryParser).
+func (b *Base) SetHistory(history queryparser.IHistory) {
+	b.history = history
+
+	if b.qp != nil {
+		b.qp.SetHistory(history)
+	}
 }
 
 func (b *Base) SetCounterDB(db *libmap.TSafeMapCounter) {
 	b.counterDB = db
 }
 
+func (b *Base) SetStateDB(db *StateDB) {
+	b.stateDB = db
+}
+
+func (b *Base) SetSilenceDB(db *SilenceDB) {
+	b.silenceDB = db
+}
+
 // SetReadersDataInBytes pulls readers data and store them on ReadersData field.
 func (b *Base) SetReadersDataInBytes(readersJsonBytes map[string][]byte) {
 	b.ReadersDataBytes = readersJsonBytes
@@ -144,6 +203,11 @@ func (b *Base) SetReadersDataInBytes(readersJsonBytes map[string][]byte) {
 
 // SetTags assigns all host tags to qp (QueryParser).
 func (b *Base) SetTags(tags map[string]string) {
+	b.tags = tags
+
+	if b.qp == nil {
+		b.SetQueryParser(nil)
+	}
 	b.qp.SetTags(tags)
 }
 
@@ -164,7 +228,12 @@ func (b *Base) IsConditionMet() bool {
 		b.Conditions = "true"
 	}
 
+	if b.qp == nil {
+		b.SetQueryParser(nil)
+	}
+
 	result, err := b.qp.Parse(b.Conditions)
+	b.conditionsErr = err
 	if err != nil {
 		return false
 	}
@@ -179,6 +248,11 @@ func (b *Base) IsConditionMet() bool {
 	return result
 }
 
+// ConditionsError returns the error, if any, from the last IsConditionMet call.
+func (b *Base) ConditionsError() error {
+	return b.conditionsErr
+}
+
 func (b *Base) LowThresholdExceeded() bool {
 	return int64(b.counterDB.Get(b.Path)) > b.LowThreshold
 }
@@ -190,6 +264,94 @@ func (b *Base) HighThresholdExceeded() bool {
 	return int64(b.counterDB.Get(b.Path)) > b.HighThreshold
 }
 
+// CheckState evaluates Conditions and thresholds, then moves the executor to its next state.
+// It decides whether actions should be performed, given silences, Cooldown and RepeatInterval.
+// State, and Silence when silenced, are recorded on data.
+// When Conditions cannot be evaluated, the state is left as is.
+func (b *Base) CheckState(data map[string]interface{}) State {
+	if b.stateDB == nil {
+		b.stateDB = NewStateDB()
+	}
+
+	b.fire, b.resolve = false, false
+
+	conditionMet := b.IsConditionMet()
+
+	if b.ConditionsError() != nil {
+		b.state, b.stateChanged = b.stateDB.Get(b.Path), false
+	} else {
+		b.state, b.stateChanged = b.stateDB.Transition(b.Path, conditionMet, conditionMet && b.LowThresholdExceeded())
+	}
+
+	var silence Silence
+	silenced := false
+
+	if b.silenceDB != nil {
+		silence, silenced = b.silenceDB.Match("/x"+b.Path, b.tags)
+	}
+
+	now := time.Now()
+
+	switch {
+	case silenced:
+
+	case b.state.State == StateFiring && !b.HighThresholdExceeded():
+		b.fire = b.elapsedSinceAction(b.Cooldown, now) && (b.stateChanged || b.elapsedSinceAction(b.RepeatInterval, now))
+
+	case b.stateChanged && b.state.State == StateResolved:
+		// Only resolve what has been acted on.
+		b.resolve = b.state.ActedAt >= b.state.FiredAt
+	}
+
+	if b.fire || b.resolve {
+		b.state = b.stateDB.Acted(b.Path, now.UnixNano())
+	}
+
+	if data != nil {
+		data["State"] = b.state.State
+
+		if silenced {
+			data["Silence"] = silence
+		} else {
+			delete(data, "Silence")
+		}
+	}
+
+	return b.state
+}
+
+// elapsedSinceAction checks if duration has passed since the last action. Empty duration has always passed.
+func (b *Base) elapsedSinceAction(duration string, now time.Time) bool {
+	if duration == "" {
+		return true
+	}
+
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"Error":    err.Error(),
+			"Path":     b.Path,
+			"Duration": duration,
+		}).Error("Failed to parse executor Cooldown or RepeatInterval")
+
+		return true
+	}
+
+	return now.Sub(time.Unix(0, b.state.ActedAt)) >= d
+}
+
+// IsFiring returns true when the executor should perform its actions.
+// It is FIRING, HighThreshold has not been exceeded, and it is neither silenced nor cooling down.
+func (b *Base) IsFiring() bool {
+	return b.fire
+}
+
+// IsResolved returns true when the executor has just moved from FIRING to RESOLVED,
+// after having acted on FIRING. Executors perform their recovery actions when it is true.
+func (b *Base) IsResolved() bool {
+	return b.resolve
+}
+
 // NewHttpRequest builds and returns http.Request struct.
 func (b *Base) NewHttpRequest(dataJson []byte) (*http.Request, error) {
 	var err error
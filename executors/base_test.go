@@ -3,6 +3,7 @@ package executors
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	resourced_config "github.com/resourced/resourced/config"
 	"github.com/resourced/resourced/libmap"
@@ -129,3 +130,112 @@ func TestRunAndCheckConditionsMet(t *testing.T) {
 		t.Errorf("Ran 2 times. LowThreshold should have been exceeded. Counter: %v", counterDB.Get("/x/uptime"))
 	}
 }
+
+func TestRunReportsConditionsError(t *testing.T) {
+	config := newConfigExecutorForTest(t)
+	config.GoStructFields["Conditions"] = `/r/load-avg.LoadAvg1m <`
+
+	data := make(map[string][]byte)
+	data["/r/load-avg"] = []byte(`{"Data": {"LoadAvg1m": 0.904296875}}`)
+
+	executor, err := NewGoStructByConfig(config)
+	if err != nil {
+		t.Fatalf("Shell constructor did not do its job. Error: %v", err)
+	}
+
+	counterDB := libmap.NewTSafeMapCounter(nil)
+	executor.SetCounterDB(counterDB)
+	executor.SetReadersDataInBytes(data)
+
+	err = executor.Run()
+	if err == nil {
+		t.Fatalf("Running with invalid conditions should return error.")
+	}
+	if executor.ConditionsError() != err {
+		t.Fatalf("Run should return the conditions error. Error: %v", err)
+	}
+}
+
+func TestShellResolveCommand(t *testing.T) {
+	config := newConfigExecutorForTest(t)
+	config.GoStructFields["Command"] = "echo firing"
+	config.GoStructFields["ResolveCommand"] = "echo resolved"
+	config.GoStructFields["Conditions"] = `/r/load-avg.LoadAvg1m > 0.5`
+
+	executor, err := NewGoStructByConfig(config)
+	if err != nil {
+		t.Fatalf("Shell constructor did not do its job. Error: %v", err)
+	}
+
+	executor.SetCounterDB(libmap.NewTSafeMapCounter(nil))
+	executor.SetStateDB(NewStateDB())
+
+	for _, loadAvg := range []string{"0.9", "0.1"} {
+		shell := executor.(*Shell)
+		shell.Data = make(map[string]interface{})
+
+		executor.SetReadersDataInBytes(map[string][]byte{"/r/load-avg": []byte(`{"Data": {"LoadAvg1m": ` + loadAvg + `}}`)})
+
+		err = executor.Run()
+		if err != nil {
+			t.Fatalf("Run should work. Error: %v", err)
+		}
+
+		if loadAvg == "0.9" && (shell.Data["State"] != StateFiring || shell.Data["Output"] != "firing\n") {
+			t.Fatalf("Shell should run Command when FIRING. Data: %v", shell.Data)
+		}
+		if loadAvg == "0.1" && (shell.Data["State"] != StateResolved || shell.Data["Output"] != "resolved\n") {
+			t.Fatalf("Shell should run ResolveCommand when RESOLVED. Data: %v", shell.Data)
+		}
+	}
+}
+
+func TestCooldownAndSilence(t *testing.T) {
+	config := newConfigExecutorForTest(t)
+	config.Path = "/uptime"
+	config.GoStructFields["Command"] = "echo firing"
+	config.GoStructFields["Cooldown"] = "1h"
+	config.GoStructFields["Conditions"] = `true`
+
+	silenceDB, _ := NewSilenceDB("")
+	stateDB := NewStateDB()
+	counterDB := libmap.NewTSafeMapCounter(nil)
+
+	run := func() map[string]interface{} {
+		executor, err := NewGoStructByConfig(config)
+		if err != nil {
+			t.Fatalf("Shell constructor did not do its job. Error: %v", err)
+		}
+
+		executor.SetCounterDB(counterDB)
+		executor.SetStateDB(stateDB)
+		executor.SetSilenceDB(silenceDB)
+
+		err = executor.Run()
+		if err != nil {
+			t.Fatalf("Run should work. Error: %v", err)
+		}
+		return executor.(*Shell).Data
+	}
+
+	if data := run(); data["Output"] != "firing\n" {
+		t.Fatalf("First run should fire. Data: %v", data)
+	}
+	if data := run(); data["Output"] != nil {
+		t.Fatalf("Second run should be cooling down. Data: %v", data)
+	}
+
+	// Without cooldown, actions repeat on every run unless silenced.
+	delete(config.GoStructFields, "Cooldown")
+
+	if data := run(); data["Output"] != "firing\n" {
+		t.Fatalf("Run without cooldown should fire. Data: %v", data)
+	}
+
+	silenceDB.Add(Silence{Path: "/x/uptime"}, time.Hour)
+
+	data := run()
+	if data["Output"] != nil || data["Silence"] == nil || data["State"] != StateFiring {
+		t.Fatalf("Silenced run should not fire, but report its silence. Data: %v", data)
+	}
+}
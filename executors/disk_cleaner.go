@@ -13,7 +13,7 @@ import (
 )
 
 func init() {
-	Register("DiskCleaner", NewDiskCleaner)
+	Register("DiskCleaner", NewDiskCleaner, "Removes files matching Globs when Conditions are met.")
 }
 
 func NewDiskCleaner() IExecutor {
@@ -26,19 +26,19 @@ func NewDiskCleaner() IExecutor {
 type DiskCleaner struct {
 	Base
 	Data  map[string]interface{}
-	Globs []interface{}
+	Globs []string `description:"File patterns to remove." example:"~/*.log,/tmp/*.gz"`
 }
 
 // Run shells out external program and store the output on c.Data.
 func (dc *DiskCleaner) Run() error {
 	dc.Data["Conditions"] = dc.Conditions
+	dc.CheckState(dc.Data)
 
-	if dc.IsConditionMet() && dc.LowThresholdExceeded() && !dc.HighThresholdExceeded() {
+	if dc.IsFiring() {
 		successOutput := make([]string, 0)
 		failOutput := make([]string, 0)
 
-		for _, globInterface := range dc.Globs {
-			glob := globInterface.(string)
+		for _, glob := range dc.Globs {
 			glob = libstring.ExpandTildeAndEnv(glob)
 
 			matches, err := filepath.Glob(glob)
@@ -79,7 +79,7 @@ func (dc *DiskCleaner) Run() error {
 		}
 	}
 
-	return nil
+	return dc.ConditionsError()
 }
 
 // ToJson serialize Data field to JSON.
@@ -9,7 +9,7 @@ import (
 )
 
 func init() {
-	Register("HipChat", NewHipChat)
+	Register("HipChat", NewHipChat, "Sends Message to a HipChat room when Conditions are met.")
 }
 
 func NewHipChat() IExecutor {
@@ -22,16 +22,32 @@ func NewHipChat() IExecutor {
 type HipChat struct {
 	Base
 	Data      map[string]interface{}
-	AuthToken string
-	RoomName  string
-	Message   string
+	AuthToken string `description:"HipChat API token."`
+	RoomName  string `description:"HipChat room to notify." example:"Ops"`
+	Message   string `description:"Message sent when Conditions are met." example:"Disk is almost full"`
+
+	// ResolvedMessage is sent once Conditions are no longer met after FIRING.
+	ResolvedMessage string `default:"Recovered" description:"Message sent once Conditions are no longer met."`
 }
 
-// Run shells out external program and store the output on c.Data.
+// Run notifies the room when the executor is FIRING and once it is RESOLVED.
 func (hc *HipChat) Run() error {
 	hc.Data["Conditions"] = hc.Conditions
+	hc.CheckState(hc.Data)
+
+	var message string
+
+	if hc.IsFiring() {
+		message = fmt.Sprintf("Conditions: %v. Message: %v.", hc.Conditions, hc.Message)
+	} else if hc.IsResolved() {
+		resolvedMessage := hc.ResolvedMessage
+		if resolvedMessage == "" {
+			resolvedMessage = "Recovered"
+		}
+		message = fmt.Sprintf("Resolved conditions: %v. Message: %v.", hc.Conditions, resolvedMessage)
+	}
 
-	if hc.IsConditionMet() && hc.LowThresholdExceeded() && !hc.HighThresholdExceeded() {
+	if message != "" {
 		c := hipchat.NewClient(hc.AuthToken)
 
 		rooms, _, err := c.Room.List()
@@ -39,8 +55,6 @@ func (hc *HipChat) Run() error {
 			return err
 		}
 
-		message := fmt.Sprintf("Conditions: %v. Message: %v.", hc.Conditions, hc.Message)
-
 		hc.Data["Message"] = message
 
 		notificationReq := &hipchat.NotificationRequest{Message: hc.Data["Message"].(string)}
@@ -60,13 +74,9 @@ func (hc *HipChat) Run() error {
 				logrus.Error(err)
 			}
 		}()
-
-		if err != nil {
-			return err
-		}
 	}
 
-	return nil
+	return hc.ConditionsError()
 }
 
 // ToJson serialize Data field to JSON.
@@ -9,7 +9,7 @@ import (
 )
 
 func init() {
-	Register("PagerDuty", NewPagerDuty)
+	Register("PagerDuty", NewPagerDuty, "Triggers a PagerDuty incident when Conditions are met, and resolves it afterwards.")
 }
 
 func NewPagerDuty() IExecutor {
@@ -22,21 +22,26 @@ func NewPagerDuty() IExecutor {
 type PagerDuty struct {
 	Base
 	Data        map[string]interface{}
-	ServiceKey  string
-	Description string
-	IncidentKey string
+	ServiceKey  string `description:"PagerDuty service integration key."`
+	Description string `description:"Incident description." example:"Load average is too high"`
+	IncidentKey string `description:"Incident deduplication key, derived from host and Path when empty."`
 }
 
-// Run shells out external program and store the output on c.Data.
+// Run triggers an incident when the executor is FIRING and resolves it once RESOLVED.
 func (pd *PagerDuty) Run() error {
 	pd.Data["Conditions"] = pd.Conditions
+	pd.CheckState(pd.Data)
 
-	if pd.IsConditionMet() && pd.LowThresholdExceeded() && !pd.HighThresholdExceeded() {
-		event := pagerduty.NewTriggerEvent(pd.ServiceKey, pd.Description)
+	var event *pagerduty.Event
 
-		if pd.IncidentKey != "" {
-			event.IncidentKey = pd.IncidentKey
-		}
+	if pd.IsFiring() {
+		event = pagerduty.NewTriggerEvent(pd.ServiceKey, pd.Description)
+	} else if pd.IsResolved() {
+		event = pagerduty.NewResolveEvent(pd.ServiceKey, pd.Description)
+	}
+
+	if event != nil {
+		event.IncidentKey = pd.incidentKey()
 
 		response, statusCode, err := pagerduty.Submit(event)
 
@@ -61,7 +66,21 @@ func (pd *PagerDuty) Run() error {
 		}
 	}
 
-	return nil
+	return pd.ConditionsError()
+}
+
+// incidentKey defaults to hostname and executor path, so that resolve events find the incident triggered earlier.
+func (pd *PagerDuty) incidentKey() string {
+	if pd.IncidentKey != "" {
+		return pd.IncidentKey
+	}
+
+	hostname := ""
+	if pd.Host != nil {
+		hostname = pd.Host.Name
+	}
+
+	return fmt.Sprintf("resourced:%v:%v", hostname, pd.Path)
 }
 
 // ToJson serialize Data field to JSON.
@@ -70,7 +89,7 @@ func (pd *PagerDuty) ToJson() ([]byte, error) {
 }
 
 func (pd *PagerDuty) formatBeforeSendingToMaster(data map[string]interface{}) []string {
-	logline := fmt.Sprintf("Conditions: %v. IncidentKey: %v. ", pd.Conditions, pd.IncidentKey)
+	logline := fmt.Sprintf("Conditions: %v. State: %v. IncidentKey: %v. ", pd.Conditions, data["State"], pd.incidentKey())
 
 	if status, ok := data["Status"]; ok {
 		logline = logline + fmt.Sprintf("Status: %v. ", status)
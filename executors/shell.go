@@ -3,6 +3,7 @@ package executors
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/Sirupsen/logrus"
 
@@ -10,7 +11,7 @@ import (
 )
 
 func init() {
-	Register("Shell", NewShell)
+	Register("Shell", NewShell, "Runs Command when Conditions are met, and ResolveCommand afterwards.")
 }
 
 func NewShell() IExecutor {
@@ -22,33 +23,61 @@ func NewShell() IExecutor {
 
 type Shell struct {
 	Base
+	libprocess.Options
 	Data map[string]interface{}
+
+	// ResolveCommand: Shell command to execute once Conditions are no longer met after FIRING.
+	ResolveCommand string `description:"Command to run once Conditions are no longer met." example:"echo resolved"`
 }
 
-// Run shells out external program and store the output on c.Data.
+// Run shells out Command when the executor is FIRING, or ResolveCommand once it is RESOLVED,
+// and store the output on s.Data.
 func (s *Shell) Run() error {
 	s.Data["Conditions"] = s.Conditions
+	s.CheckState(s.Data)
+
+	command := ""
+
+	options := s.Options
 
-	if s.IsConditionMet() && s.LowThresholdExceeded() && !s.HighThresholdExceeded() {
-		output, err := libprocess.NewCmd(s.Command).CombinedOutput()
-		s.Data["Output"] = string(output)
+	if s.IsFiring() {
+		command = s.Command
+	} else if s.IsResolved() {
+		command = s.ResolveCommand
+		// Args are the firing command.
+		options.Args = nil
+	}
+
+	if command != "" || len(options.Args) > 0 {
+		output := ""
+
+		result, err := options.Run(command, nil)
+		if result != nil {
+			output = string(result.Stdout)
+			s.Data["Stderr"] = strings.TrimSpace(string(result.Stderr))
+			s.Data["ExitStatus"] = result.ExitStatus
+		} else {
+			s.Data["Stderr"] = ""
+			s.Data["ExitStatus"] = 1
+		}
+
+		s.Data["Output"] = output
 
 		if err != nil {
 			s.Data["Error"] = err.Error()
-			s.Data["ExitStatus"] = 1
 		} else {
-			s.Data["ExitStatus"] = 0
+			s.Data["Error"] = ""
 		}
 
 		go func() {
-			err := s.SendToMaster([]string{fmt.Sprintf("Conditions: %v. Output: %v.", s.Conditions, string(output))})
+			err := s.SendToMaster([]string{fmt.Sprintf("Conditions: %v. State: %v. Output: %v.", s.Conditions, s.Data["State"], output)})
 			if err != nil {
 				logrus.Error(err)
 			}
 		}()
 	}
 
-	return nil
+	return s.ConditionsError()
 }
 
 // ToJson serialize Data field to JSON.
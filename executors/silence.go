@@ -0,0 +1,199 @@
+package executors
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Silence suppresses actions of executors matching Path, or host Tags, until ExpiresAt.
+type Silence struct {
+	ID string
+
+	// Path: Executor path, e.g. /x/uptime.
+	Path string `json:",omitempty"`
+
+	// Tags: Every tag must match host tags.
+	Tags map[string]string `json:",omitempty"`
+
+	Comment string `json:",omitempty"`
+
+	// CreatedAt and ExpiresAt are in UnixNano.
+	CreatedAt int64
+	ExpiresAt int64
+}
+
+// Matches checks whether the silence applies to executor path, on a host with tags.
+func (s Silence) Matches(path string, tags map[string]string) bool {
+	if s.Path != "" && s.Path != path {
+		return false
+	}
+
+	for key, value := range s.Tags {
+		if tags[key] != value {
+			return false
+		}
+	}
+
+	return s.Path != "" || len(s.Tags) > 0
+}
+
+// NewSilenceDB creates an instance of SilenceDB.
+// When filename is defined, silences are loaded from it and every change is saved into it.
+func NewSilenceDB(filename string) (*SilenceDB, error) {
+	db := &SilenceDB{}
+	db.filename = filename
+	db.silences = make(map[string]Silence)
+
+	if filename == "" {
+		return db, nil
+	}
+
+	silencesJson, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(silencesJson, &db.silences)
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// SilenceDB is concurrency-safe collection of silences, keyed by ID.
+type SilenceDB struct {
+	filename string
+	silences map[string]Silence
+	sync.RWMutex
+}
+
+// Add creates a silence lasting duration. Path may be given with or without the /x prefix.
+func (db *SilenceDB) Add(silence Silence, duration time.Duration) (Silence, error) {
+	if duration <= 0 {
+		return silence, errors.New("Silence duration must be positive.")
+	}
+
+	if silence.Path != "" && !strings.HasPrefix(silence.Path, "/x/") {
+		silence.Path = "/x" + silence.Path
+	}
+	if silence.Path == "" && len(silence.Tags) == 0 {
+		return silence, errors.New("Silence must match Path or Tags.")
+	}
+
+	id := make([]byte, 8)
+	_, err := rand.Read(id)
+	if err != nil {
+		return silence, err
+	}
+
+	now := time.Now()
+
+	silence.ID = hex.EncodeToString(id)
+	silence.CreatedAt = now.UnixNano()
+	silence.ExpiresAt = now.Add(duration).UnixNano()
+
+	db.Lock()
+	defer db.Unlock()
+
+	db.silences[silence.ID] = silence
+
+	return silence, db.save()
+}
+
+// Delete removes a silence. It returns false when there is no such silence.
+func (db *SilenceDB) Delete(id string) (bool, error) {
+	db.Lock()
+	defer db.Unlock()
+
+	if _, ok := db.silences[id]; !ok {
+		return false, nil
+	}
+
+	delete(db.silences, id)
+
+	return true, db.save()
+}
+
+// All returns silences that have not expired, ordered by expiration.
+func (db *SilenceDB) All() []Silence {
+	db.RLock()
+	defer db.RUnlock()
+
+	now := time.Now().UnixNano()
+
+	silences := make([]Silence, 0)
+	for _, silence := range db.silences {
+		if silence.ExpiresAt > now {
+			silences = append(silences, silence)
+		}
+	}
+
+	sort.Slice(silences, func(i, j int) bool {
+		return silences[i].ExpiresAt < silences[j].ExpiresAt
+	})
+
+	return silences
+}
+
+// Match returns the longest lasting silence for executor path, on a host with tags.
+func (db *SilenceDB) Match(path string, tags map[string]string) (Silence, bool) {
+	var match Silence
+	found := false
+
+	for _, silence := range db.All() {
+		if silence.Matches(path, tags) {
+			match = silence
+			found = true
+		}
+	}
+
+	return match, found
+}
+
+// save writes all silences into filename, expired silences are dropped.
+// Callers must hold the lock.
+func (db *SilenceDB) save() error {
+	now := time.Now().UnixNano()
+
+	for id, silence := range db.silences {
+		if silence.ExpiresAt <= now {
+			delete(db.silences, id)
+		}
+	}
+
+	if db.filename == "" {
+		return nil
+	}
+
+	silencesJson, err := json.Marshal(db.silences)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(db.filename), 0755)
+	if err != nil {
+		return err
+	}
+
+	tmpFilename := db.filename + ".tmp"
+
+	err = ioutil.WriteFile(tmpFilename, silencesJson, 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFilename, db.filename)
+}
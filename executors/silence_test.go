@@ -0,0 +1,68 @@
+package executors
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSilenceDBPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "resourced-silences")
+	if err != nil {
+		t.Fatalf("Creating temp dir should work. Error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "silences.json")
+
+	db, err := NewSilenceDB(filename)
+	if err != nil {
+		t.Fatalf("Creating SilenceDB should work. Error: %v", err)
+	}
+
+	_, err = db.Add(Silence{}, time.Hour)
+	if err == nil {
+		t.Fatalf("Silence without Path or Tags should fail.")
+	}
+
+	byPath, err := db.Add(Silence{Path: "/uptime"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Adding silence should work. Error: %v", err)
+	}
+	if byPath.Path != "/x/uptime" {
+		t.Fatalf("Silence path should be prefixed with /x. Path: %v", byPath.Path)
+	}
+
+	_, err = db.Add(Silence{Tags: map[string]string{"role": "appserver"}}, time.Hour)
+	if err != nil {
+		t.Fatalf("Adding silence should work. Error: %v", err)
+	}
+
+	db, err = NewSilenceDB(filename)
+	if err != nil {
+		t.Fatalf("Reloading SilenceDB should work. Error: %v", err)
+	}
+	if len(db.All()) != 2 {
+		t.Fatalf("Silences should survive reload. Silences: %v", db.All())
+	}
+
+	if _, silenced := db.Match("/x/uptime", nil); !silenced {
+		t.Errorf("/x/uptime should be silenced by path.")
+	}
+	if _, silenced := db.Match("/x/other", map[string]string{"role": "appserver", "env": "prod"}); !silenced {
+		t.Errorf("/x/other should be silenced by tags.")
+	}
+	if _, silenced := db.Match("/x/other", map[string]string{"role": "dbserver"}); silenced {
+		t.Errorf("/x/other should not be silenced.")
+	}
+
+	found, err := db.Delete(byPath.ID)
+	if !found || err != nil {
+		t.Fatalf("Deleting silence should work. Error: %v", err)
+	}
+	if _, silenced := db.Match("/x/uptime", nil); silenced {
+		t.Errorf("/x/uptime should no longer be silenced.")
+	}
+}
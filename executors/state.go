@@ -0,0 +1,154 @@
+package executors
+
+import (
+	"sync"
+	"time"
+)
+
+// Alert states of an executor.
+const (
+	// StateOK: Conditions are not met.
+	StateOK = "OK"
+
+	// StatePending: Conditions are met, but LowThreshold has not been exceeded yet.
+	StatePending = "PENDING"
+
+	// StateFiring: Conditions are met and LowThreshold has been exceeded.
+	StateFiring = "FIRING"
+
+	// StateResolved: Conditions are no longer met after FIRING.
+	StateResolved = "RESOLVED"
+)
+
+// State is the alert state of an executor path.
+type State struct {
+	State string
+
+	// Since is when State was entered, in UnixNano.
+	Since int64
+
+	// CheckedAt is when Conditions were last evaluated, in UnixNano.
+	CheckedAt int64
+
+	// FiredAt and ResolvedAt are when the executor last entered FIRING and RESOLVED, in UnixNano.
+	FiredAt    int64 `json:",omitempty"`
+	ResolvedAt int64 `json:",omitempty"`
+
+	// ActedAt is when the executor last performed an action, in UnixNano.
+	ActedAt int64 `json:",omitempty"`
+}
+
+// nextState returns the state following current, given the latest Conditions check.
+func nextState(current string, conditionMet, lowThresholdExceeded bool) string {
+	if conditionMet {
+		if lowThresholdExceeded {
+			return StateFiring
+		}
+		if current == StateFiring {
+			return StateFiring
+		}
+		return StatePending
+	}
+
+	if current == StateFiring {
+		return StateResolved
+	}
+	return StateOK
+}
+
+// NewStateDB creates an instance of StateDB.
+func NewStateDB() *StateDB {
+	db := &StateDB{}
+	db.states = make(map[string]State)
+
+	return db
+}
+
+// StateDB is concurrency-safe map of executor states, keyed by executor path.
+type StateDB struct {
+	states map[string]State
+	sync.RWMutex
+}
+
+// Get returns the state of path. Unknown paths are OK.
+func (db *StateDB) Get(path string) State {
+	db.RLock()
+	defer db.RUnlock()
+
+	state, ok := db.states[path]
+	if !ok {
+		state.State = StateOK
+	}
+
+	return state
+}
+
+// Transition moves path to its next state and returns the new state,
+// and whether the state has changed.
+func (db *StateDB) Transition(path string, conditionMet, lowThresholdExceeded bool) (State, bool) {
+	db.Lock()
+	defer db.Unlock()
+
+	now := time.Now().UnixNano()
+
+	state, ok := db.states[path]
+	if !ok {
+		state = State{State: StateOK, Since: now}
+	}
+
+	next := nextState(state.State, conditionMet, lowThresholdExceeded)
+	changed := next != state.State
+
+	if changed {
+		state.State = next
+		state.Since = now
+
+		if next == StateFiring {
+			state.FiredAt = now
+		}
+		if next == StateResolved {
+			state.ResolvedAt = now
+		}
+	}
+	state.CheckedAt = now
+
+	db.states[path] = state
+
+	return state, changed
+}
+
+// Acted records that the executor of path has performed an action at unixNano.
+func (db *StateDB) Acted(path string, unixNano int64) State {
+	db.Lock()
+	defer db.Unlock()
+
+	state, ok := db.states[path]
+	if !ok {
+		state = State{State: StateOK, Since: unixNano}
+	}
+
+	state.ActedAt = unixNano
+	db.states[path] = state
+
+	return state
+}
+
+// All returns a copy of all states.
+func (db *StateDB) All() map[string]State {
+	db.RLock()
+	defer db.RUnlock()
+
+	copydata := make(map[string]State)
+	for path, state := range db.states {
+		copydata[path] = state
+	}
+
+	return copydata
+}
+
+// Delete removes the state of path.
+func (db *StateDB) Delete(path string) {
+	db.Lock()
+	delete(db.states, path)
+	db.Unlock()
+}
@@ -0,0 +1,40 @@
+package executors
+
+import (
+	"testing"
+)
+
+func TestStateTransitions(t *testing.T) {
+	db := NewStateDB()
+
+	steps := []struct {
+		conditionMet         bool
+		lowThresholdExceeded bool
+		expected             string
+		changed              bool
+	}{
+		{false, false, StateOK, false},
+		{true, false, StatePending, true},
+		{true, true, StateFiring, true},
+		{true, true, StateFiring, false},
+		{false, false, StateResolved, true},
+		{false, false, StateOK, true},
+		{true, false, StatePending, true},
+		{false, false, StateOK, true},
+	}
+
+	for i, step := range steps {
+		state, changed := db.Transition("/uptime", step.conditionMet, step.lowThresholdExceeded)
+		if state.State != step.expected || changed != step.changed {
+			t.Fatalf("Step %v should be %v (changed: %v). State: %v, Changed: %v", i, step.expected, step.changed, state.State, changed)
+		}
+	}
+
+	state := db.Get("/uptime")
+	if state.FiredAt == 0 || state.ResolvedAt == 0 || state.ResolvedAt < state.FiredAt {
+		t.Fatalf("FiredAt and ResolvedAt should be recorded. State: %+v", state)
+	}
+	if db.Get("/does-not-exist").State != StateOK {
+		t.Fatalf("Unknown path should be OK.")
+	}
+}
@@ -0,0 +1,108 @@
+package libencoding
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+)
+
+func init() {
+	Register("csv", &CsvEncoder{})
+}
+
+// CsvEncoder writes text/csv with a header row. Nested fields become dotted columns, e.g. Memory.RSS.
+//
+// Rows are:
+//   - Elements of a list.
+//   - Values of an object whose values are all objects, e.g. processes keyed by pid. The object keys are in the first column, Key.
+//   - The object itself otherwise.
+//
+// A record of a reader, writer, or executor is tabulated by its Data.
+type CsvEncoder struct{}
+
+func (e *CsvEncoder) ContentType() string {
+	return "text/csv"
+}
+
+func (e *CsvEncoder) Encode(w io.Writer, data interface{}) error {
+	keys, rows := table(data)
+
+	columns := make([]string, 0)
+	seen := make(map[string]bool)
+	flatRows := make([]map[string]string, len(rows))
+
+	for i, row := range rows {
+		flatRows[i] = make(map[string]string)
+
+		for _, pair := range Flatten(row) {
+			if pair.Key == "" {
+				pair.Key = "Value"
+			}
+			if !seen[pair.Key] {
+				seen[pair.Key] = true
+				columns = append(columns, pair.Key)
+			}
+			flatRows[i][pair.Key] = formatScalar(pair.Value)
+		}
+	}
+	sort.Strings(columns)
+
+	if keys != nil {
+		columns = append([]string{"Key"}, columns...)
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Write(columns)
+
+	for i, flatRow := range flatRows {
+		record := make([]string, len(columns))
+
+		for j, column := range columns {
+			if keys != nil && j == 0 {
+				record[j] = keys[i]
+			} else {
+				record[j] = flatRow[column]
+			}
+		}
+		writer.Write(record)
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// table splits data into rows. keys is not nil when rows are values of an object.
+func table(data interface{}) (keys []string, rows []interface{}) {
+	switch value := data.(type) {
+	case []interface{}:
+		return nil, value
+
+	case map[string]interface{}:
+		if _, ok := value["Data"]; ok && value["Path"] != nil && value["UnixNano"] != nil {
+			return table(value["Data"])
+		}
+
+		if len(value) == 0 {
+			return nil, []interface{}{}
+		}
+
+		for _, child := range value {
+			if _, ok := child.(map[string]interface{}); !ok {
+				return nil, []interface{}{value}
+			}
+		}
+
+		keys = make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			rows = append(rows, value[key])
+		}
+		return keys, rows
+	}
+
+	return nil, []interface{}{data}
+}
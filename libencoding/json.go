@@ -0,0 +1,21 @@
+package libencoding
+
+import (
+	"encoding/json"
+	"io"
+)
+
+func init() {
+	Register("json", &JsonEncoder{})
+}
+
+// JsonEncoder writes application/json.
+type JsonEncoder struct{}
+
+func (e *JsonEncoder) ContentType() string {
+	return "application/json"
+}
+
+func (e *JsonEncoder) Encode(w io.Writer, data interface{}) error {
+	return json.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,160 @@
+// Package libencoding encodes JSON data of readers, writers, and executors in other formats.
+package libencoding
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var encoders = map[string]IEncoder{}
+
+// Register makes an encoder available by name, e.g. ?format=csv.
+func Register(name string, encoder IEncoder) {
+	if encoder == nil {
+		panic("libencoding: Register encoder is nil")
+	}
+	if _, dup := encoders[name]; dup {
+		panic("libencoding: Register called twice for encoder " + name)
+	}
+	encoders[name] = encoder
+}
+
+// IEncoder writes data decoded by Decode.
+type IEncoder interface {
+	ContentType() string
+	Encode(w io.Writer, data interface{}) error
+}
+
+// Names returns names of all registered encoders.
+func Names() []string {
+	names := make([]string, 0, len(encoders))
+	for name := range encoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Negotiate picks an encoder by format name, or by the Accept header when format is empty.
+// JSON is picked when Accept does not list any supported media type.
+func Negotiate(format, accept string) (IEncoder, error) {
+	if format != "" {
+		encoder, ok := encoders[format]
+		if !ok {
+			return nil, fmt.Errorf("Unsupported format: %v. Supported formats: %v", format, strings.Join(Names(), ", "))
+		}
+		return encoder, nil
+	}
+
+	type mediaRange struct {
+		mediaType string
+		quality   float64
+	}
+
+	ranges := make([]mediaRange, 0)
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+		}
+
+		if quality > 0 {
+			ranges = append(ranges, mediaRange{mediaType, quality})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].quality > ranges[j].quality })
+
+	for _, r := range ranges {
+		for _, name := range Names() {
+			if encoders[name].ContentType() == r.mediaType || (name == "msgpack" && r.mediaType == "application/x-msgpack") {
+				return encoders[name], nil
+			}
+		}
+	}
+
+	return encoders["json"], nil
+}
+
+// Decode decodes JSON data. Numbers are kept as json.Number so large integers such as UnixNano are not rounded.
+func Decode(jsonData []byte) (interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(string(jsonData)))
+	decoder.UseNumber()
+
+	var data interface{}
+
+	err := decoder.Decode(&data)
+	return data, err
+}
+
+// KeyValue is a leaf of flattened data.
+type KeyValue struct {
+	Key   string
+	Value interface{}
+}
+
+// Flatten turns nested data into leaves keyed by dotted paths, e.g. Data.Interfaces.0.Name, sorted by key.
+func Flatten(data interface{}) []KeyValue {
+	pairs := make([]KeyValue, 0)
+	flatten("", data, &pairs)
+	return pairs
+}
+
+func flatten(prefix string, data interface{}, pairs *[]KeyValue) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch value := data.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			flatten(join(key), value[key], pairs)
+		}
+
+	case []interface{}:
+		for i, element := range value {
+			flatten(join(strconv.Itoa(i)), element, pairs)
+		}
+
+	default:
+		*pairs = append(*pairs, KeyValue{Key: prefix, Value: data})
+	}
+}
+
+// formatScalar renders a leaf value as text. null is empty.
+func formatScalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,96 @@
+package libencoding
+
+import (
+	"bytes"
+	"testing"
+)
+
+func encodeForTest(t *testing.T, name, jsonData string) string {
+	data, err := Decode([]byte(jsonData))
+	if err != nil {
+		t.Fatalf("Decoding JSON should work. Error: %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	err = encoders[name].Encode(&buf, data)
+	if err != nil {
+		t.Fatalf("Encoding %v should work. Error: %v", name, err)
+	}
+	return buf.String()
+}
+
+func TestNegotiate(t *testing.T) {
+	expectations := map[string]string{
+		"":                                      "application/json",
+		"*/*":                                   "application/json",
+		"text/csv":                              "text/csv",
+		"application/x-msgpack":                 "application/msgpack",
+		"text/plain;q=0.5, text/csv;q=0.9":      "text/csv",
+		"text/csv;q=0, text/plain":              "text/plain",
+		"text/html, application/xhtml+xml, */*": "application/json",
+	}
+
+	for accept, contentType := range expectations {
+		encoder, err := Negotiate("", accept)
+		if err != nil || encoder.ContentType() != contentType {
+			t.Fatalf("Accept: %v should pick %v. Error: %v", accept, contentType, err)
+		}
+	}
+
+	encoder, err := Negotiate("msgpack", "text/csv")
+	if err != nil || encoder.ContentType() != "application/msgpack" {
+		t.Fatalf("format should take precedence over Accept. Error: %v", err)
+	}
+
+	_, err = Negotiate("xml", "")
+	if err == nil {
+		t.Fatalf("Unsupported format should fail.")
+	}
+}
+
+func TestCsvEncoder(t *testing.T) {
+	csv := encodeForTest(t, "csv", `{"Path": "/ps", "UnixNano": 1, "Data": {"2": {"Name": "sh", "Memory": {"RSS": 10}}, "1": {"Name": "init, 1"}}}`)
+
+	expected := "Key,Memory.RSS,Name\n1,,\"init, 1\"\n2,10,sh\n"
+	if csv != expected {
+		t.Fatalf("Record should be tabulated by Data. CSV: %q", csv)
+	}
+
+	csv = encodeForTest(t, "csv", `[{"A": 1}, {"B": true}]`)
+	if csv != "A,B\n1,\n,true\n" {
+		t.Fatalf("List should be tabulated by element. CSV: %q", csv)
+	}
+
+	csv = encodeForTest(t, "csv", `{"LoadAvg1m": 0.5, "LoadAvg5m": 1}`)
+	if csv != "LoadAvg1m,LoadAvg5m\n0.5,1\n" {
+		t.Fatalf("Object should be a single row. CSV: %q", csv)
+	}
+}
+
+func TestTextEncoder(t *testing.T) {
+	text := encodeForTest(t, "text", `{"UnixNano": 1448939513473829400, "Data": {"Interfaces": [{"Name": "eth0"}], "Motd": "a\nb", "Up": null}}`)
+
+	expected := "Data.Interfaces.0.Name=eth0\nData.Motd=\"a\\nb\"\nData.Up=\nUnixNano=1448939513473829400\n"
+	if text != expected {
+		t.Fatalf("Text should contain a line per leaf. Text: %q", text)
+	}
+}
+
+func TestMsgpackEncoder(t *testing.T) {
+	msgpack := encodeForTest(t, "msgpack", `{"a": [1, -1, 300, 1.5, true, null], "b": "xy"}`)
+
+	expected := []byte{
+		0x82,
+		0xa1, 'a', 0x96, 0x01, 0xff, 0xd1, 0x01, 0x2c, 0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0, 0xc3, 0xc0,
+		0xa1, 'b', 0xa2, 'x', 'y',
+	}
+	if !bytes.Equal([]byte(msgpack), expected) {
+		t.Fatalf("Msgpack should follow the spec. Msgpack: % x", msgpack)
+	}
+
+	msgpack = encodeForTest(t, "msgpack", `1448939513473829400`)
+	if !bytes.Equal([]byte(msgpack), []byte{0xd3, 0x14, 0x1b, 0xaa, 0xcf, 0x89, 0xa2, 0x8a, 0x18}) {
+		t.Fatalf("Large integers should not be rounded. Msgpack: % x", msgpack)
+	}
+}
@@ -0,0 +1,175 @@
+package libencoding
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+)
+
+func init() {
+	Register("msgpack", &MsgpackEncoder{})
+}
+
+// MsgpackEncoder writes application/msgpack, see https://github.com/msgpack/msgpack/blob/master/spec.md.
+// Object keys are written in sorted order. Integral numbers are written as integers, others as float 64.
+type MsgpackEncoder struct{}
+
+func (e *MsgpackEncoder) ContentType() string {
+	return "application/msgpack"
+}
+
+func (e *MsgpackEncoder) Encode(w io.Writer, data interface{}) error {
+	buffered := bufio.NewWriter(w)
+
+	err := (&msgpackWriter{buffered}).write(data)
+	if err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+type msgpackWriter struct {
+	w *bufio.Writer
+}
+
+func (m *msgpackWriter) bytes(b ...byte) {
+	m.w.Write(b)
+}
+
+// header writes a type byte followed by a big endian length or number of size bytes.
+func (m *msgpackWriter) header(code byte, size int, n uint64) {
+	buf := make([]byte, 9)
+	buf[0] = code
+
+	switch size {
+	case 1:
+		buf[1] = byte(n)
+	case 2:
+		binary.BigEndian.PutUint16(buf[1:], uint16(n))
+	case 4:
+		binary.BigEndian.PutUint32(buf[1:], uint32(n))
+	case 8:
+		binary.BigEndian.PutUint64(buf[1:], n)
+	}
+
+	m.w.Write(buf[:1+size])
+}
+
+// length writes the header of a string, array, or map: fixed when n is below fixedMax, then 8, 16, or 32 bits.
+func (m *msgpackWriter) length(n int, fixed byte, fixedMax int, code8, code16, code32 byte) {
+	switch {
+	case n < fixedMax:
+		m.bytes(fixed | byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		m.header(code8, 1, uint64(n))
+	case n <= math.MaxUint16:
+		m.header(code16, 2, uint64(n))
+	default:
+		m.header(code32, 4, uint64(n))
+	}
+}
+
+func (m *msgpackWriter) int(n int64) {
+	switch {
+	case n >= 0 && n <= 127:
+		m.bytes(byte(n))
+	case n >= -32 && n < 0:
+		m.bytes(byte(n))
+	case n >= math.MinInt8 && n <= math.MaxInt8:
+		m.header(0xd0, 1, uint64(n))
+	case n >= math.MinInt16 && n <= math.MaxInt16:
+		m.header(0xd1, 2, uint64(n))
+	case n >= math.MinInt32 && n <= math.MaxInt32:
+		m.header(0xd2, 4, uint64(n))
+	default:
+		m.header(0xd3, 8, uint64(n))
+	}
+}
+
+func (m *msgpackWriter) float(f float64) {
+	m.header(0xcb, 8, math.Float64bits(f))
+}
+
+func (m *msgpackWriter) write(data interface{}) error {
+	switch value := data.(type) {
+	case nil:
+		m.bytes(0xc0)
+
+	case bool:
+		if value {
+			m.bytes(0xc3)
+		} else {
+			m.bytes(0xc2)
+		}
+
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			m.int(n)
+		} else if f, err := value.Float64(); err == nil {
+			m.float(f)
+		} else {
+			return err
+		}
+
+	case int:
+		m.int(int64(value))
+
+	case int64:
+		m.int(value)
+
+	case float64:
+		if value == math.Trunc(value) && math.Abs(value) < 1<<53 {
+			m.int(int64(value))
+		} else {
+			m.float(value)
+		}
+
+	case string:
+		m.length(len(value), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		m.w.WriteString(value)
+
+	case []interface{}:
+		m.length(len(value), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, element := range value {
+			err := m.write(element)
+			if err != nil {
+				return err
+			}
+		}
+
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		m.length(len(value), 0x80, 16, 0, 0xde, 0xdf)
+		for _, key := range keys {
+			m.write(key)
+
+			err := m.write(value[key])
+			if err != nil {
+				return err
+			}
+		}
+
+	default:
+		// Other types, e.g. structs, are written the way encoding/json sees them.
+		jsonData, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		decoded, err := Decode(jsonData)
+		if err != nil {
+			return err
+		}
+		return m.write(decoded)
+	}
+
+	return nil
+}
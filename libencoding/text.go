@@ -0,0 +1,35 @@
+package libencoding
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Register("text", &TextEncoder{})
+}
+
+// TextEncoder writes one key.path=value line per leaf, friendly to grep and cut.
+// Values containing line breaks or quotes are quoted.
+type TextEncoder struct{}
+
+func (e *TextEncoder) ContentType() string {
+	return "text/plain"
+}
+
+func (e *TextEncoder) Encode(w io.Writer, data interface{}) error {
+	for _, pair := range Flatten(data) {
+		value := formatScalar(pair.Value)
+		if strings.ContainsAny(value, "\r\n\"") {
+			value = strconv.Quote(value)
+		}
+
+		_, err := fmt.Fprintf(w, "%v=%v\n", pair.Key, value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -57,6 +57,23 @@ func HandleErrorJson(w http.ResponseWriter, err error) {
 	http.Error(w, string(errJson), http.StatusInternalServerError)
 }
 
+// HandleErrorJsonWithStatusCode wraps error in JSON structure and responds with the given status code.
+func HandleErrorJsonWithStatusCode(w http.ResponseWriter, err error, statusCode int) {
+	var errMap map[string]string
+
+	if err == nil {
+		errMap = map[string]string{"Error": "Error struct is nil."}
+	} else {
+		errMap = map[string]string{"Error": err.Error()}
+	}
+
+	errJson, _ := json.Marshal(errMap)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(errJson)
+}
+
 // HandleErrorHTML wraps error in HTML.
 func HandleErrorHTML(w http.ResponseWriter, err error, statusCode int) {
 	data := struct {
@@ -2,22 +2,334 @@
 package libprocess
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/user"
+	"sort"
 	"strings"
+	"syscall"
+	"time"
+
+	"github.com/resourced/resourced/libstring"
 )
 
 // NewCmd is a convenience function to construct `*exec.Cmd` from string input.
+// command is split like a shell does, see SplitCommand. When it cannot be split, it is split on whitespace.
 func NewCmd(command string) *exec.Cmd {
-	wd, _ := os.Getwd()
-
-	parts := strings.Fields(command)
-	head := parts[0]
-	parts = parts[1:len(parts)]
+	cmd, err := Options{}.Cmd(command)
+	if err != nil {
+		parts := strings.Fields(command)
+		if len(parts) == 0 {
+			parts = []string{""}
+		}
 
-	cmd := exec.Command(head, parts...)
-	cmd.Dir = wd
-	cmd.Env = os.Environ()
+		cmd = exec.Command(parts[0], parts[1:]...)
+		cmd.Dir, _ = os.Getwd()
+		cmd.Env = os.Environ()
+	}
 
 	return cmd
 }
+
+// SplitCommand splits command into program and arguments like a shell does, without expanding anything:
+// words are separated by whitespace, single quotes keep everything literally,
+// and backslashes escape the next character, or ", \, $, and ` inside double quotes.
+func SplitCommand(command string) ([]string, error) {
+	words := make([]string, 0)
+
+	var word bytes.Buffer
+	inWord := false
+
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+
+		case c == '\\':
+			if i+1 == len(command) {
+				return nil, fmt.Errorf("Command ends with an escaping backslash. Given: %v", command)
+			}
+			i++
+			word.WriteByte(command[i])
+			inWord = true
+
+		case c == '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end == -1 {
+				return nil, fmt.Errorf("Command has an unterminated single quote. Given: %v", command)
+			}
+			word.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+
+		case c == '"':
+			i++
+			for ; i < len(command) && command[i] != '"'; i++ {
+				if command[i] == '\\' && i+1 < len(command) && strings.IndexByte("\"\\$`", command[i+1]) != -1 {
+					i++
+				}
+				word.WriteByte(command[i])
+			}
+			if i == len(command) {
+				return nil, fmt.Errorf("Command has an unterminated double quote. Given: %v", command)
+			}
+			inWord = true
+
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	if len(words) == 0 {
+		return nil, errors.New("Command is empty.")
+	}
+	return words, nil
+}
+
+// Options tells how commands run. Readers, writers, and executors running commands embed it.
+type Options struct {
+	// Args is the program and its arguments, passed as is. It is used when Command is empty.
+	Args []string `description:"Program and arguments to run as is, instead of Command." example:"[\"/usr/lib/nagios/plugins/check_disk\", \"-w\", \"10%\"]"`
+
+	// Shell runs Command with /bin/sh -c, so it can use pipes, redirections, and variables.
+	Shell bool `description:"Run Command with /bin/sh -c, allowing pipes and redirections."`
+
+	// Timeout kills the command and every process it started after this duration. Zero means no timeout.
+	Timeout time.Duration `description:"Kill the command and its children after this duration." example:"30s"`
+
+	// Env are environment variables added to the environment of the agent, or replacing it when ClearEnv is true.
+	Env map[string]string `description:"Environment variables given to the command." example:"{LANG = \"C\"}"`
+
+	// ClearEnv starts commands with only PATH and Env, instead of the environment of the agent.
+	ClearEnv bool `description:"Do not pass the environment of the agent, only PATH and Env."`
+
+	// Dir is the working directory. Defaults to the working directory of the agent.
+	Dir string `description:"Working directory of the command." example:"/tmp"`
+
+	// User and Group run the command as another user, by name or id. The agent must run as root.
+	// Group defaults to the primary group of User.
+	User  string `description:"User to run the command as. The agent must run as root." example:"nobody"`
+	Group string `description:"Group to run the command as. Defaults to the primary group of User." example:"nogroup"`
+
+	// ctx kills the command and every process it started once it is done, see SetContext.
+	ctx context.Context
+}
+
+// SetContext makes Run kill the command and every process it started once ctx is done,
+// e.g. when the run of a config timed out.
+func (o *Options) SetContext(ctx context.Context) {
+	o.ctx = ctx
+}
+
+// Result is the outcome of a command run by Options.Run.
+type Result struct {
+	Stdout     []byte
+	Stderr     []byte
+	ExitStatus int
+	TimedOut   bool
+}
+
+// TimeoutError is returned by Options.Run when a command is killed after Timeout.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("Command timed out after %v.", e.Timeout)
+}
+
+// Cmd constructs `*exec.Cmd` running command, or Args when command is empty.
+func (o Options) Cmd(command string) (*exec.Cmd, error) {
+	var parts []string
+
+	if command != "" && o.Shell {
+		parts = []string{"/bin/sh", "-c", command}
+
+	} else if command != "" {
+		var err error
+
+		parts, err = SplitCommand(command)
+		if err != nil {
+			return nil, err
+		}
+
+	} else if len(o.Args) > 0 {
+		parts = o.Args
+
+	} else {
+		return nil, errors.New("Command is empty.")
+	}
+
+	cmd := exec.Command(parts[0], parts[1:]...)
+
+	if o.Dir != "" {
+		cmd.Dir = libstring.ExpandTildeAndEnv(o.Dir)
+	} else {
+		cmd.Dir, _ = os.Getwd()
+	}
+
+	env, err := o.environ()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Env = env
+
+	cmd.SysProcAttr, err = o.sysProcAttr()
+	if err != nil {
+		return nil, err
+	}
+
+	return cmd, nil
+}
+
+// environ returns the environment of commands, sorted by name.
+func (o Options) environ() ([]string, error) {
+	vars := make(map[string]string)
+
+	if o.ClearEnv {
+		vars["PATH"] = os.Getenv("PATH")
+	} else {
+		for _, keyValue := range os.Environ() {
+			if equal := strings.Index(keyValue, "="); equal > 0 {
+				vars[keyValue[:equal]] = keyValue[equal+1:]
+			}
+		}
+	}
+
+	// The home directory and names of the agent's user are wrong for another user.
+	if o.User != "" {
+		u, err := lookupUser(o.User)
+		if err != nil {
+			return nil, err
+		}
+		vars["HOME"] = u.HomeDir
+		vars["USER"] = u.Username
+		vars["LOGNAME"] = u.Username
+	}
+
+	for key, value := range o.Env {
+		vars[key] = value
+	}
+
+	env := make([]string, 0, len(vars))
+	for key, value := range vars {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+
+	return env, nil
+}
+
+// lookupUser finds a user by name or id.
+func lookupUser(name string) (*user.User, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		u, err = user.LookupId(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("User %v does not exist.", name)
+	}
+	return u, nil
+}
+
+// lookupGroup finds a group id by name or id.
+func lookupGroup(name string) (string, error) {
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		g, err = user.LookupGroupId(name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("Group %v does not exist.", name)
+	}
+	return g.Gid, nil
+}
+
+// Run executes command, or Args when command is empty, with stdin as standard input.
+// Standard output and standard error are captured separately.
+// A Result is returned whenever the command started, even when it failed, timed out, or was killed by SetContext.
+func (o Options) Run(command string, stdin io.Reader) (*Result, error) {
+	cmd, err := o.Cmd(command)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := o.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	result := &Result{}
+
+	var timedOut <-chan time.Time
+	if o.Timeout > 0 {
+		timer := time.NewTimer(o.Timeout)
+		defer timer.Stop()
+		timedOut = timer.C
+	}
+
+	select {
+	case err = <-done:
+	case <-timedOut:
+		// Children holding standard output open would block Wait, kill them too.
+		killProcessGroup(cmd.Process)
+		<-done
+
+		result.TimedOut = true
+		err = &TimeoutError{Timeout: o.Timeout}
+
+	case <-ctx.Done():
+		killProcessGroup(cmd.Process)
+		<-done
+
+		err = ctx.Err()
+	}
+
+	result.Stdout = stdout.Bytes()
+	result.Stderr = stderr.Bytes()
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			result.ExitStatus = status.ExitStatus()
+		}
+	}
+	if err != nil && result.ExitStatus <= 0 {
+		result.ExitStatus = 1
+	}
+
+	return result, err
+}
@@ -0,0 +1,117 @@
+package libprocess
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := map[string][]string{
+		`uptime`:                          {"uptime"},
+		`  df  -h	/ `:                     {"df", "-h", "/"},
+		`echo 'hello world' "it's" a\ b`:  {"echo", "hello world", "it's", "a b"},
+		`echo "say \"hi\" \n" '\n'`:       {"echo", `say "hi" \n`, `\n`},
+		`grep -e '' x""y`:                 {"grep", "-e", "", "xy"},
+		`check_disk -w 10% -p "/var/lib"`: {"check_disk", "-w", "10%", "-p", "/var/lib"},
+	}
+
+	for command, expected := range tests {
+		words, err := SplitCommand(command)
+		if err != nil {
+			t.Errorf("Splitting %v should work. Error: %v", command, err)
+		}
+		if !reflect.DeepEqual(words, expected) {
+			t.Errorf("Splitting %v should return %q. Words: %q", command, expected, words)
+		}
+	}
+
+	for _, command := range []string{``, `   `, `echo 'unterminated`, `echo "unterminated`, `echo \`} {
+		_, err := SplitCommand(command)
+		if err == nil {
+			t.Errorf("Splitting %q should fail.", command)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	result, err := Options{}.Run(`sh -c 'echo out; echo err >&2; exit 3'`, nil)
+	if err == nil {
+		t.Errorf("Command exiting with 3 should fail.")
+	}
+	if string(result.Stdout) != "out\n" || string(result.Stderr) != "err\n" {
+		t.Errorf("Standard output and standard error should be separated. Stdout: %q, Stderr: %q", result.Stdout, result.Stderr)
+	}
+	if result.ExitStatus != 3 {
+		t.Errorf("Exit status should be 3. ExitStatus: %v", result.ExitStatus)
+	}
+
+	result, err = Options{Args: []string{"echo", "$HOME", "a  b"}}.Run("", nil)
+	if err != nil || string(result.Stdout) != "$HOME a  b\n" {
+		t.Errorf("Args should be passed as is. Error: %v, Stdout: %q", err, result.Stdout)
+	}
+
+	result, err = Options{Shell: true}.Run("echo hello | tr a-z A-Z", strings.NewReader("ignored"))
+	if err != nil || string(result.Stdout) != "HELLO\n" {
+		t.Errorf("Shell should run pipes. Error: %v, Stdout: %q", err, result.Stdout)
+	}
+
+	result, err = Options{Env: map[string]string{"GREETING": "hi"}, ClearEnv: true, Dir: "/"}.Run(`sh -c 'echo $GREETING $HOME; pwd'`, nil)
+	if err != nil || string(result.Stdout) != "hi\n/\n" {
+		t.Errorf("Env and Dir should be set. Error: %v, Stdout: %q", err, result.Stdout)
+	}
+
+	_, err = Options{}.Run("", nil)
+	if err == nil {
+		t.Errorf("Empty command should fail.")
+	}
+
+	_, err = Options{User: "user-that-does-not-exist"}.Run("true", nil)
+	if err == nil {
+		t.Errorf("Unknown User should fail.")
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	startedAt := time.Now()
+
+	// The child sleep holds standard output open, it must be killed too.
+	result, err := Options{Shell: true, Timeout: 100 * time.Millisecond}.Run("echo started; sleep 5 | cat", nil)
+	if _, ok := err.(*TimeoutError); !ok {
+		t.Fatalf("Command exceeding Timeout should fail. Error: %v", err)
+	}
+	if !result.TimedOut || string(result.Stdout) != "started\n" {
+		t.Errorf("Result should be kept on timeout. Result: %+v", result)
+	}
+	if time.Since(startedAt) > 2*time.Second {
+		t.Errorf("Command and its children should be killed on timeout. Duration: %v", time.Since(startedAt))
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	startedAt := time.Now()
+
+	options := Options{Shell: true}
+	options.SetContext(ctx)
+
+	result, err := options.Run("echo started; sleep 5 | cat", nil)
+	if err != context.Canceled {
+		t.Fatalf("Command should fail once its context is done. Error: %v", err)
+	}
+	if result == nil || string(result.Stdout) != "started\n" {
+		t.Errorf("Result should be kept once its context is done. Result: %+v", result)
+	}
+	if time.Since(startedAt) > 2*time.Second {
+		t.Errorf("Command and its children should be killed once its context is done. Duration: %v", time.Since(startedAt))
+	}
+
+	_, err = options.Run("true", nil)
+	if err != context.Canceled {
+		t.Errorf("Command should not start once its context is done. Error: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+// +build !windows
+
+package libprocess
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// sysProcAttr starts commands in their own process group, so Timeout kills their children too,
+// and switches to User and Group when given.
+func (o Options) sysProcAttr() (*syscall.SysProcAttr, error) {
+	attr := &syscall.SysProcAttr{Setpgid: true}
+
+	if o.User == "" && o.Group == "" {
+		return attr, nil
+	}
+
+	credential := &syscall.Credential{Uid: uint32(os.Getuid()), Gid: uint32(os.Getgid())}
+
+	gid := ""
+
+	if o.User != "" {
+		u, err := lookupUser(o.User)
+		if err != nil {
+			return nil, err
+		}
+
+		uid, err := strconv.ParseUint(u.Uid, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		credential.Uid = uint32(uid)
+		gid = u.Gid
+	}
+
+	if o.Group != "" {
+		var err error
+
+		gid, err = lookupGroup(o.Group)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if gid != "" {
+		parsedGid, err := strconv.ParseUint(gid, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		credential.Gid = uint32(parsedGid)
+	}
+
+	attr.Credential = credential
+
+	return attr, nil
+}
+
+// killProcessGroup kills process and every process it started.
+func killProcessGroup(process *os.Process) {
+	err := syscall.Kill(-process.Pid, syscall.SIGKILL)
+	if err != nil {
+		process.Kill()
+	}
+}
@@ -0,0 +1,20 @@
+package libprocess
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// sysProcAttr cannot switch users on Windows.
+func (o Options) sysProcAttr() (*syscall.SysProcAttr, error) {
+	if o.User != "" || o.Group != "" {
+		return nil, errors.New("User and Group are not supported on Windows.")
+	}
+	return nil, nil
+}
+
+// killProcessGroup kills process. Processes it started are left running.
+func killProcessGroup(process *os.Process) {
+	process.Kill()
+}
@@ -16,7 +16,7 @@ func ExpandTilde(path string) string {
 		return path
 	}
 
-	if path[:2] == "~/" {
+	if strings.HasPrefix(path, "~/") {
 		usr, err := user.Current()
 		if err != nil || usr == nil {
 			return path
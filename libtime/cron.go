@@ -0,0 +1,213 @@
+package libtime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronDescriptors are shorthands of common cron expressions.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronField is the range and the names accepted by one field of a cron expression.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// Cron is a schedule defined by a standard 5 fields cron expression: minute, hour, day of month, month, and day of week.
+// Times are matched in the location of the time given to Next.
+type Cron struct {
+	Expression string
+
+	minutes, hours, days, months, weekdays uint64
+
+	// When both day of month and day of week are restricted, a day matching either runs, like cron does.
+	daysRestricted, weekdaysRestricted bool
+}
+
+// ParseCron parses a cron expression, e.g. "*/5 * * * *", "0 9 * * mon-fri", or a descriptor, e.g. "@hourly".
+func ParseCron(expression string) (*Cron, error) {
+	expression = strings.TrimSpace(expression)
+
+	definition := expression
+	if descriptor, ok := cronDescriptors[strings.ToLower(definition)]; ok {
+		definition = descriptor
+	}
+
+	fields := strings.Fields(definition)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("Cron expression must have 5 fields: minute, hour, day of month, month, and day of week. Given: %v", expression)
+	}
+
+	bits := make([]uint64, len(fields))
+
+	for i, field := range fields {
+		var err error
+
+		bits[i], err = cronFields[i].parse(strings.ToLower(field))
+		if err != nil {
+			return nil, fmt.Errorf("Cron expression %v is invalid: %v", expression, err)
+		}
+	}
+
+	// Sunday is both 0 and 7.
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	cron := &Cron{
+		Expression:         expression,
+		minutes:            bits[0],
+		hours:              bits[1],
+		days:               bits[2],
+		months:             bits[3],
+		weekdays:           bits[4],
+		daysRestricted:     fields[2] != "*",
+		weekdaysRestricted: fields[4] != "*",
+	}
+
+	if cron.Next(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, fmt.Errorf("Cron expression %v never matches", expression)
+	}
+
+	return cron, nil
+}
+
+// parse turns a field, e.g. "1-10/2,30", into a bit set of the values it matches.
+func (f cronField) parse(field string) (uint64, error) {
+	var bits uint64
+
+	for _, item := range strings.Split(field, ",") {
+		rangePart, step := item, 1
+
+		if slash := strings.Index(item, "/"); slash != -1 {
+			var err error
+
+			rangePart = item[:slash]
+			step, err = strconv.Atoi(item[slash+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("%v step must be a positive number. Given: %v", f.name, item)
+			}
+		}
+
+		from, to := f.min, f.max
+
+		switch {
+		case rangePart == "*":
+
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+
+			var err error
+
+			from, err = f.value(bounds[0])
+			if err != nil {
+				return 0, err
+			}
+			to, err = f.value(bounds[1])
+			if err != nil {
+				return 0, err
+			}
+			if from > to {
+				return 0, fmt.Errorf("%v range must be ascending. Given: %v", f.name, rangePart)
+			}
+
+		default:
+			var err error
+
+			from, err = f.value(rangePart)
+			if err != nil {
+				return 0, err
+			}
+			// A single value with a step, e.g. 5/15, runs from the value to the maximum.
+			if step == 1 {
+				to = from
+			}
+		}
+
+		for value := from; value <= to; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+
+	return bits, nil
+}
+
+// value parses a number or a name, e.g. jan or mon, within the range of the field.
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if s == name {
+			if f.min == 1 {
+				return i + 1, nil
+			}
+			return i, nil
+		}
+	}
+
+	value, err := strconv.Atoi(s)
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("%v must be between %v and %v. Given: %v", f.name, f.min, f.max, s)
+	}
+	return value, nil
+}
+
+// matchesDay tells if the day of t is matched by day of month and day of week fields.
+func (c *Cron) matchesDay(t time.Time) bool {
+	day := c.days&(1<<uint(t.Day())) != 0
+	weekday := c.weekdays&(1<<uint(t.Weekday())) != 0
+
+	if c.daysRestricted && c.weekdaysRestricted {
+		return day || weekday
+	}
+	return day && weekday
+}
+
+// Next returns the first minute after t matched by the expression.
+// It returns zero time when nothing matches within 5 years, e.g. for February 30.
+func (c *Cron) Next(t time.Time) time.Time {
+	location := t.Location()
+
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, location).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, location)
+			continue
+		}
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, location)
+			continue
+		}
+		if c.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, location)
+			continue
+		}
+		if c.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
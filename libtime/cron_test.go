@@ -0,0 +1,47 @@
+package libtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	from := time.Date(2016, 1, 29, 10, 7, 30, 0, time.UTC)
+
+	expected := map[string]time.Time{
+		"*/5 * * * *":       time.Date(2016, 1, 29, 10, 10, 0, 0, time.UTC),
+		"@hourly":           time.Date(2016, 1, 29, 11, 0, 0, 0, time.UTC),
+		"@daily":            time.Date(2016, 1, 30, 0, 0, 0, 0, time.UTC),
+		"0 9 * * mon-fri":   time.Date(2016, 2, 1, 9, 0, 0, 0, time.UTC),
+		"30 8 1,15 * *":     time.Date(2016, 2, 1, 8, 30, 0, 0, time.UTC),
+		"0 0 29 feb *":      time.Date(2016, 2, 29, 0, 0, 0, 0, time.UTC),
+		"0 0 13 * 5":        time.Date(2016, 1, 29, 0, 0, 0, 0, time.UTC).AddDate(0, 0, 7),
+		"0 12 * * 7":        time.Date(2016, 1, 31, 12, 0, 0, 0, time.UTC),
+		"5/20 10 * * *":     time.Date(2016, 1, 29, 10, 25, 0, 0, time.UTC),
+		"0-10/10 10-12 * *": time.Time{},
+	}
+
+	for expression, next := range expected {
+		cron, err := ParseCron(expression)
+		if next.IsZero() {
+			if err == nil {
+				t.Errorf("Invalid cron expression should fail. Expression: %v", expression)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parsing cron expression should work. Expression: %v, Error: %v", expression, err)
+			continue
+		}
+
+		if got := cron.Next(from); !got.Equal(next) {
+			t.Errorf("Next run is incorrect. Expression: %v, Expected: %v, Got: %v", expression, next, got)
+		}
+	}
+
+	for _, expression := range []string{"60 * * * *", "* * * * * *", "*/0 * * * *", "10-5 * * * *", "0 0 30 2 *", "@often"} {
+		if _, err := ParseCron(expression); err == nil {
+			t.Errorf("Invalid cron expression should fail. Expression: %v", expression)
+		}
+	}
+}
@@ -2,6 +2,10 @@
 package libtime
 
 import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,3 +19,41 @@ func SleepString(definition string) error {
 	time.Sleep(delayTime)
 	return nil
 }
+
+// SleepStringContext is like SleepString but wakes up early when ctx is done.
+func SleepStringContext(ctx context.Context, definition string) error {
+	delayTime, err := time.ParseDuration(definition)
+	if err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(delayTime)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ParseTimeOrAgo parses unix timestamp in seconds, RFC3339 timestamp, or a duration relative to now.
+// Duration is interpreted as "ago", so both "15m" and "-15m" mean 15 minutes before now.
+func ParseTimeOrAgo(definition string, now time.Time) (time.Time, error) {
+	definition = strings.TrimSpace(definition)
+
+	if unixSeconds, err := strconv.ParseFloat(definition, 64); err == nil {
+		return time.Unix(0, int64(unixSeconds*float64(time.Second))), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, definition); err == nil {
+		return t, nil
+	}
+
+	if ago, err := time.ParseDuration(strings.TrimPrefix(definition, "-")); err == nil {
+		return now.Add(-ago), nil
+	}
+
+	return time.Time{}, errors.New("Time must be unix timestamp, RFC3339 timestamp, or duration. Given: " + definition)
+}
@@ -1,7 +1,9 @@
 package libtime
 
 import (
+	"context"
 	"testing"
+	"time"
 )
 
 func TestSleepString(t *testing.T) {
@@ -9,3 +11,46 @@ func TestSleepString(t *testing.T) {
 		t.Errorf("Failed to sleep")
 	}
 }
+
+func TestSleepStringContext(t *testing.T) {
+	if SleepStringContext(context.Background(), "1ms") != nil {
+		t.Errorf("Failed to sleep")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	if SleepStringContext(ctx, "1h") != context.Canceled {
+		t.Errorf("Cancelled context should stop the sleep")
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Cancelled context should stop the sleep immediately")
+	}
+}
+
+func TestParseTimeOrAgo(t *testing.T) {
+	now := time.Now()
+
+	parsed, err := ParseTimeOrAgo("1445552400", now)
+	if err != nil || parsed.Unix() != 1445552400 {
+		t.Errorf("Failed to parse unix timestamp. Parsed: %v, Error: %v", parsed, err)
+	}
+
+	parsed, err = ParseTimeOrAgo("2015-10-22T22:20:00Z", now)
+	if err != nil || parsed.Unix() != 1445552400 {
+		t.Errorf("Failed to parse RFC3339 timestamp. Parsed: %v, Error: %v", parsed, err)
+	}
+
+	for _, definition := range []string{"15m", "-15m"} {
+		parsed, err = ParseTimeOrAgo(definition, now)
+		if err != nil || !parsed.Equal(now.Add(-15*time.Minute)) {
+			t.Errorf("Failed to parse duration as ago. Parsed: %v, Error: %v", parsed, err)
+		}
+	}
+
+	_, err = ParseTimeOrAgo("yesterday", now)
+	if err == nil {
+		t.Errorf("Invalid time should fail to parse")
+	}
+}
@@ -0,0 +1,156 @@
+package libtime
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Schedule tells when a loop runs.
+type Schedule interface {
+	// Next returns the first run time after t.
+	Next(t time.Time) time.Time
+}
+
+// Every is a schedule running every Interval.
+// When Align is true, runs happen at multiples of Interval on the wall clock, e.g. every 10m runs at :00, :10, :20...
+type Every struct {
+	Interval time.Duration
+	Align    bool
+}
+
+// Next returns t plus Interval, or the next multiple of Interval on the wall clock when aligned.
+func (e *Every) Next(t time.Time) time.Time {
+	if !e.Align {
+		return t.Add(e.Interval)
+	}
+
+	// Truncate works on absolute time, shift by the zone offset to align on the local wall clock.
+	_, offset := t.Zone()
+	zoneOffset := time.Duration(offset) * time.Second
+
+	return t.Add(zoneOffset).Truncate(e.Interval).Add(e.Interval).Add(-zoneOffset)
+}
+
+// ParseSchedule parses definition: a duration, e.g. "1m", or a cron expression, e.g. "*/5 * * * *" or "@hourly".
+// align only applies to durations, cron expressions are always aligned on the wall clock.
+func ParseSchedule(definition string, align bool) (Schedule, error) {
+	definition = strings.TrimSpace(definition)
+
+	interval, durationErr := time.ParseDuration(definition)
+	if durationErr == nil {
+		if interval <= 0 {
+			return nil, fmt.Errorf("Interval must be positive. Given: %v", definition)
+		}
+		return &Every{Interval: interval, Align: align}, nil
+	}
+
+	if strings.HasPrefix(definition, "@") || len(strings.Fields(definition)) > 1 {
+		return ParseCron(definition)
+	}
+
+	return nil, fmt.Errorf("Interval must be a duration or a cron expression. Error: %v", durationErr)
+}
+
+// Scheduler computes run times of a loop from its Schedule.
+// Run times are derived from the previous run time, not from the end of the previous run, so they do not drift.
+// Runs missed while the previous run was still running are skipped.
+type Scheduler struct {
+	Schedule Schedule
+
+	// Splay delays every run by the same duration between 0 and Splay, picked from SplayKey.
+	// Agents of a fleet with distinct SplayKey, e.g. their hostnames, do not run at the same second.
+	Splay    time.Duration
+	SplayKey string
+
+	// Jitter delays each run by a random duration between 0 and Jitter.
+	Jitter time.Duration
+
+	// Skipped counts run times that passed before Next was called, e.g. because the previous run was still going on.
+	Skipped int64
+
+	scheduled time.Time
+}
+
+// NewScheduler parses interval, jitter, and splay. Empty jitter and splay mean no delay.
+func NewScheduler(interval string, align bool, jitter, splay, splayKey string) (*Scheduler, error) {
+	schedule, err := ParseSchedule(interval, align)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduler := &Scheduler{Schedule: schedule, SplayKey: splayKey}
+
+	if jitter != "" {
+		scheduler.Jitter, err = time.ParseDuration(jitter)
+		if err != nil || scheduler.Jitter < 0 {
+			return nil, fmt.Errorf("Jitter must be a positive duration. Given: %v", jitter)
+		}
+	}
+
+	if splay != "" {
+		scheduler.Splay, err = time.ParseDuration(splay)
+		if err != nil || scheduler.Splay < 0 {
+			return nil, fmt.Errorf("Splay must be a positive duration. Given: %v", splay)
+		}
+	}
+
+	return scheduler, nil
+}
+
+// splayOffset is the fixed delay of SplayKey, between 0 and Splay.
+func (s *Scheduler) splayOffset() time.Duration {
+	if s.Splay <= 0 {
+		return 0
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(s.SplayKey))
+
+	return time.Duration(hash.Sum64() % uint64(s.Splay))
+}
+
+// Next returns the next run time after now, with splay and jitter.
+// The first run of an unaligned duration is right away, others wait for their first scheduled time.
+func (s *Scheduler) Next(now time.Time) time.Time {
+	splay := s.splayOffset()
+
+	if s.scheduled.IsZero() {
+		if every, ok := s.Schedule.(*Every); ok && !every.Align {
+			s.scheduled = now.Add(-every.Interval)
+		} else {
+			s.scheduled = now.Add(-splay)
+		}
+	}
+
+	next := s.Schedule.Next(s.scheduled)
+	for next.Add(splay).Before(now) {
+		next = s.Schedule.Next(next)
+		s.Skipped++
+	}
+	s.scheduled = next
+
+	next = next.Add(splay)
+	if s.Jitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(s.Jitter))))
+	}
+	return next
+}
+
+// Wait sleeps until the next run time, or until ctx is done.
+func (s *Scheduler) Wait(ctx context.Context) error {
+	now := time.Now()
+
+	timer := time.NewTimer(s.Next(now).Sub(now))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
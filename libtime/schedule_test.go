@@ -0,0 +1,107 @@
+package libtime
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	schedule, err := ParseSchedule("10m", true)
+	if err != nil {
+		t.Fatalf("Parsing duration should work. Error: %v", err)
+	}
+
+	now := time.Date(2016, 1, 29, 10, 7, 30, 0, time.UTC)
+	if next := schedule.Next(now); !next.Equal(time.Date(2016, 1, 29, 10, 10, 0, 0, time.UTC)) {
+		t.Errorf("Aligned duration should run at multiples of itself. Next: %v", next)
+	}
+
+	schedule, _ = ParseSchedule("10m", false)
+	if next := schedule.Next(now); !next.Equal(now.Add(10 * time.Minute)) {
+		t.Errorf("Unaligned duration should run after itself. Next: %v", next)
+	}
+
+	if _, err := ParseSchedule("*/5 * * * *", false); err != nil {
+		t.Errorf("Parsing cron expression should work. Error: %v", err)
+	}
+
+	for _, definition := range []string{"often", "0s", "-1m", "* * *"} {
+		if _, err := ParseSchedule(definition, false); err == nil {
+			t.Errorf("Invalid interval should fail. Interval: %v", definition)
+		}
+	}
+}
+
+func TestSchedulerDoesNotDrift(t *testing.T) {
+	scheduler, err := NewScheduler("1m", false, "", "", "")
+	if err != nil {
+		t.Fatalf("Creating scheduler should work. Error: %v", err)
+	}
+
+	start := time.Date(2016, 1, 29, 10, 7, 30, 0, time.UTC)
+
+	if next := scheduler.Next(start); !next.Equal(start) {
+		t.Errorf("First run should be right away. Next: %v", next)
+	}
+	if next := scheduler.Next(start.Add(10 * time.Second)); !next.Equal(start.Add(time.Minute)) {
+		t.Errorf("Run time should not drift by run duration. Next: %v", next)
+	}
+	if next := scheduler.Next(start.Add(150 * time.Second)); !next.Equal(start.Add(3 * time.Minute)) {
+		t.Errorf("Missed runs should be skipped. Next: %v", next)
+	}
+	if scheduler.Skipped != 1 {
+		t.Errorf("Missed runs should be counted. Skipped: %v", scheduler.Skipped)
+	}
+}
+
+func TestSchedulerSplayAndJitter(t *testing.T) {
+	now := time.Date(2016, 1, 29, 10, 7, 30, 0, time.UTC)
+
+	offsets := make(map[time.Duration]bool)
+
+	for _, host := range []string{"web-1", "web-2", "web-3", "web-4"} {
+		scheduler, err := NewScheduler("@hourly", false, "", "10m", host)
+		if err != nil {
+			t.Fatalf("Creating scheduler should work. Error: %v", err)
+		}
+
+		first := scheduler.Next(now)
+		second := scheduler.Next(first)
+
+		offset := first.Sub(time.Date(2016, 1, 29, 11, 0, 0, 0, time.UTC))
+		if offset < 0 || offset >= 10*time.Minute {
+			t.Errorf("Splay should delay run by less than Splay. Offset: %v", offset)
+		}
+		if second.Sub(first) != time.Hour {
+			t.Errorf("Splay should be the same for every run. First: %v, Second: %v", first, second)
+		}
+		offsets[offset] = true
+	}
+	if len(offsets) < 2 {
+		t.Errorf("Splay should differ between hosts. Offsets: %v", offsets)
+	}
+
+	scheduler, _ := NewScheduler("1m", true, "5s", "", "")
+	next := scheduler.Next(now)
+	if next.Before(time.Date(2016, 1, 29, 10, 8, 0, 0, time.UTC)) || !next.Before(time.Date(2016, 1, 29, 10, 8, 5, 0, time.UTC)) {
+		t.Errorf("Jitter should delay run by less than Jitter. Next: %v", next)
+	}
+
+	for _, args := range [][2]string{{"often", ""}, {"", "soon"}, {"-1s", ""}} {
+		if _, err := NewScheduler("1m", false, args[0], args[1], ""); err == nil {
+			t.Errorf("Invalid Jitter or Splay should fail. Jitter: %v, Splay: %v", args[0], args[1])
+		}
+	}
+}
+
+func TestSchedulerWait(t *testing.T) {
+	scheduler, _ := NewScheduler("1h", true, "", "", "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if scheduler.Wait(ctx) == nil {
+		t.Errorf("Wait should stop when context is done.")
+	}
+}
@@ -4,7 +4,8 @@ package loggers
 import (
 	"errors"
 	"os"
-	"reflect"
+	"sort"
+	"sync"
 
 	"github.com/hpcloud/tail"
 
@@ -13,13 +14,15 @@ import (
 )
 
 var loggerConstructors = make(map[string]func() ILogger)
+var loggerDescriptions = make(map[string]string)
 
 func init() {
-	Register("Base", NewBase)
+	Register("Base", NewBase, "Tails File and keeps up to AutoPruneLength of its lines.")
 }
 
 // Register makes any reader constructor available by name.
-func Register(name string, constructor func() ILogger) {
+// description tells what the logger does, it is shown by GET /gostructs and resourced list-gostructs.
+func Register(name string, constructor func() ILogger, description string) {
 	if constructor == nil {
 		panic("reader: Register reader constructor is nil")
 	}
@@ -27,6 +30,12 @@ func Register(name string, constructor func() ILogger) {
 		panic("reader: Register called twice for reader constructor " + name)
 	}
 	loggerConstructors[name] = constructor
+	loggerDescriptions[name] = description
+}
+
+// Description returns the description of a registered logger constructor.
+func Description(name string) string {
+	return loggerDescriptions[name]
 }
 
 // NewGoStruct instantiates ILogger
@@ -39,6 +48,16 @@ func NewGoStruct(name string) (ILogger, error) {
 	return constructor(), nil
 }
 
+// Names returns names of all registered logger constructors, sorted.
+func Names() []string {
+	names := make([]string, 0, len(loggerConstructors))
+	for name := range loggerConstructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // NewGoStructByConfig instantiates ILogger given Config struct
 func NewGoStructByConfig(config resourced_config.Config) (ILogger, error) {
 	reader, err := NewGoStruct(config.GoStruct)
@@ -46,16 +65,10 @@ func NewGoStructByConfig(config resourced_config.Config) (ILogger, error) {
 		return nil, err
 	}
 
-	// Populate ILogger fields dynamically
-	if len(config.GoStructFields) > 0 {
-		for structFieldInString, value := range config.GoStructFields {
-			goStructField := reflect.ValueOf(reader).Elem().FieldByName(structFieldInString)
-
-			if goStructField.IsValid() && goStructField.CanSet() {
-				valueOfValue := reflect.ValueOf(value)
-				goStructField.Set(valueOfValue)
-			}
-		}
+	// Populate ILogger fields, converting values to the types of its fields
+	err = resourced_config.DecodeGoStructFields(config.GoStructFields, reader)
+	if err != nil {
+		return nil, err
 	}
 
 	return reader, err
@@ -67,6 +80,7 @@ type ILogger interface {
 	GetData() *libmap.TSafeMapStrings
 	GetFile() string
 	GetAutoPruneLength() int64
+	Stop() error
 }
 
 func NewBase() ILogger {
@@ -80,9 +94,13 @@ func NewBase() ILogger {
 }
 
 type Base struct {
-	File            string
+	File            string `required:"true" description:"Log file to tail." example:"/var/log/syslog"`
 	Data            *libmap.TSafeMapStrings
-	AutoPruneLength int64
+	AutoPruneLength int64 `default:"1000000" description:"Maximum number of lines kept in memory."`
+
+	tail    *tail.Tail
+	stopped bool
+	sync.Mutex
 }
 
 // Run tails the file continuously.
@@ -91,11 +109,35 @@ func (b *Base) RunBlocking() {
 		Follow:   true,
 		Location: &tail.SeekInfo{Offset: 0, Whence: os.SEEK_END},
 	})
-	if err == nil {
-		for line := range t.Lines {
-			b.Data.Append("Loglines", line.Text)
-		}
+	if err != nil {
+		return
+	}
+
+	b.Lock()
+	if b.stopped {
+		b.Unlock()
+		t.Stop()
+		return
+	}
+	b.tail = t
+	b.Unlock()
+
+	for line := range t.Lines {
+		b.Data.Append("Loglines", line.Text)
+	}
+}
+
+// Stop stops tailing the file and unblocks RunBlocking.
+func (b *Base) Stop() error {
+	b.Lock()
+	defer b.Unlock()
+
+	b.stopped = true
+
+	if b.tail == nil {
+		return nil
 	}
+	return b.tail.Stop()
 }
 
 // GetData returns data.
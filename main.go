@@ -1,17 +1,21 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
+	"syscall"
 	"time"
 
 	"github.com/Sirupsen/logrus"
 	metrics_graphite "github.com/cyberdelia/go-metrics-graphite"
 
 	"github.com/resourced/resourced/agent"
+	resourced_config "github.com/resourced/resourced/config"
 	_ "github.com/resourced/resourced/readers/docker"
 	_ "github.com/resourced/resourced/readers/haproxy"
 	_ "github.com/resourced/resourced/readers/mcrouter"
@@ -22,12 +26,27 @@ import (
 	_ "github.com/resourced/resourced/readers/varnish"
 )
 
+// shutdownTimeout is how long HTTP requests, then in-flight runs, are each given to finish on SIGINT/SIGTERM.
+const shutdownTimeout = 30 * time.Second
+
 func init() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
 }
 
-// main runs the web server for resourced.
+// main runs the web server for resourced, or one of the commands when its name is the first argument.
 func main() {
+	// Secrets resolved from ${env:...} and ${file:...} references in configs are never logged.
+	logrus.SetFormatter(&resourced_config.RedactingFormatter{Formatter: &logrus.TextFormatter{}})
+
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
+	runAgent()
+}
+
+// runAgent runs readers, writers, executors, loggers, and the web server until SIGINT/SIGTERM.
+func runAgent() {
 	configDir := os.Getenv("RESOURCED_CONFIG_DIR")
 	if configDir == "" {
 		err := errors.New("RESOURCED_CONFIG_DIR is required")
@@ -45,6 +64,7 @@ func main() {
 	}
 
 	a.RunAllForever()
+	a.WatchConfigDirs()
 
 	// Graphite Settings
 	graphiteListener, err := a.NewTCPServer(a.GeneralConfig.Graphite, "Graphite TCP")
@@ -52,16 +72,7 @@ func main() {
 		logrus.Fatal(err)
 	}
 	if graphiteListener != nil {
-		defer graphiteListener.Close()
-
-		go func(graphiteListener net.Listener) {
-			for {
-				conn, err := graphiteListener.Accept()
-				if err == nil {
-					go a.HandleGraphite(conn)
-				}
-			}
-		}(graphiteListener)
+		a.ServeTCP(graphiteListener, a.HandleGraphite)
 	}
 
 	// LogReceiver TCP Settings
@@ -70,16 +81,7 @@ func main() {
 		logrus.Fatal(err)
 	}
 	if logReceiverListener != nil {
-		defer logReceiverListener.Close()
-
-		go func(logReceiverListener net.Listener) {
-			for {
-				conn, err := logReceiverListener.Accept()
-				if err == nil {
-					go a.HandleLog(conn)
-				}
-			}
-		}(logReceiverListener)
+		a.ServeTCP(logReceiverListener, a.HandleLog)
 	}
 
 	// Publish metrics to self graphite endpoint.
@@ -100,21 +102,73 @@ func main() {
 		"ResourcedMaster.URL": a.GeneralConfig.ResourcedMaster.URL,
 	}
 
+	server := &http.Server{Addr: a.GeneralConfig.Addr, Handler: a}
+
+	// Stream clients never hang up on their own, they would hold Shutdown until its deadline.
+	server.RegisterOnShutdown(a.CloseStreams)
+
+	serverErrors := make(chan error, 1)
+
 	if a.GeneralConfig.HTTPS.CertFile != "" && a.GeneralConfig.HTTPS.KeyFile != "" {
 		logFields["HTTPS.CertFile"] = a.GeneralConfig.HTTPS.CertFile
 		logFields["HTTPS.KeyFile"] = a.GeneralConfig.HTTPS.KeyFile
 
+		if a.GeneralConfig.HTTPS.ClientCAFile != "" {
+			logFields["HTTPS.ClientCAFile"] = a.GeneralConfig.HTTPS.ClientCAFile
+		}
+
+		server.TLSConfig, err = a.NewHTTPSConfig()
+		if err != nil {
+			logrus.WithFields(logFields).Fatal(err)
+		}
+
 		logrus.WithFields(logFields).Info("Running HTTPS server")
 
-		err = http.ListenAndServeTLS(a.GeneralConfig.Addr, a.GeneralConfig.HTTPS.CertFile, a.GeneralConfig.HTTPS.KeyFile, a.HttpRouter())
+		go func() {
+			serverErrors <- server.ListenAndServeTLS("", "")
+		}()
 
 	} else {
 		logrus.WithFields(logFields).Info("Running HTTP server")
 
-		err = http.ListenAndServe(a.GeneralConfig.Addr, a.HttpRouter())
+		go func() {
+			serverErrors <- server.ListenAndServe()
+		}()
 	}
 
-	if err != nil {
-		logrus.Fatal(err)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err = <-serverErrors:
+		if err != nil && err != http.ErrServerClosed {
+			logrus.Fatal(err)
+		}
+
+	case sig := <-signals:
+		logrus.WithFields(logrus.Fields{
+			"Signal": sig.String(),
+		}).Info("Shutting down")
+
+		serverCtx, cancelServer := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancelServer()
+
+		err = server.Shutdown(serverCtx)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"Error": err.Error(),
+			}).Error("Failed to shut down HTTP server cleanly")
+		}
+
+		// The agent gets its own deadline, a slow HTTP shutdown must not skip draining runs and loggers.
+		agentCtx, cancelAgent := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancelAgent()
+
+		err = a.Shutdown(agentCtx)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"Error": err.Error(),
+			}).Error("Failed to shut down agent cleanly")
+		}
 	}
 }
@@ -0,0 +1,490 @@
+package queryparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseSelector splits a JSON selector such as `Data.LoadAvg1m` or `["/tmp"].UsePercent` into keys.
+func ParseSelector(selector string) ([]string, error) {
+	selector = strings.TrimSpace(selector)
+	if selector == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(selector, ".") && !strings.HasPrefix(selector, "[") {
+		selector = "." + selector
+	}
+
+	tokens, err := lex(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		if t.Kind == tokenEOF {
+			break
+		}
+		if t.Kind != tokenSelector {
+			return nil, newError(t.Pos, "unexpected %v in selector", t)
+		}
+		keys = append(keys, t.Text)
+	}
+
+	return keys, nil
+}
+
+// Select walks JSON data, decoded by encoding/json, following keys. Array elements are selected by index.
+// The "*" key selects every element of an object, sorted by key, or of an array, and returns a list.
+// Elements that do not have the keys following "*" are left out of the list.
+func Select(data interface{}, keys []string) (interface{}, error) {
+	for i, key := range keys {
+		if key == "*" {
+			return selectAll(data, keys[:i], keys[i+1:])
+		}
+
+		switch value := data.(type) {
+		case map[string]interface{}:
+			child, ok := value[key]
+			if !ok {
+				return nil, fmt.Errorf("key %q does not exist", strings.Join(keys[:i+1], "."))
+			}
+			data = child
+
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(value) {
+				return nil, fmt.Errorf("index %q does not exist", strings.Join(keys[:i+1], "."))
+			}
+			data = value[index]
+
+		default:
+			return nil, fmt.Errorf("cannot select %q from %v", key, typeName(data))
+		}
+	}
+
+	return data, nil
+}
+
+func selectAll(data interface{}, parents, keys []string) ([]interface{}, error) {
+	var children []interface{}
+
+	switch value := data.(type) {
+	case map[string]interface{}:
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			children = append(children, value[name])
+		}
+
+	case []interface{}:
+		children = value
+
+	default:
+		return nil, fmt.Errorf("cannot select %q from %v", strings.Join(append(parents, "*"), "."), typeName(data))
+	}
+
+	results := make([]interface{}, 0, len(children))
+
+	for _, child := range children {
+		result, err := Select(child, keys)
+		if err != nil {
+			continue
+		}
+
+		// Nested wildcards are flattened into one list.
+		if list, ok := result.([]interface{}); ok && containsWildcard(keys) {
+			results = append(results, list...)
+		} else {
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
+}
+
+func containsWildcard(keys []string) bool {
+	for _, key := range keys {
+		if key == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// truthy converts any value to boolean: false, 0, "", null, and empty collections are false.
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+// toNumber converts numbers and numeric strings to float64, readers often report numbers as strings.
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+// evaluator evaluates a conditions tree once. Reader records are decoded at most once per evaluation.
+type evaluator struct {
+	qp      *QueryParser
+	records map[string]interface{}
+
+	// elements are being filtered by nested "where", the innermost is last.
+	elements []interface{}
+}
+
+func newEvaluator(qp *QueryParser) *evaluator {
+	return &evaluator{qp: qp, records: make(map[string]interface{})}
+}
+
+// record returns the decoded "Data" sub-structure of a reader/writer/executor path.
+func (e *evaluator) record(path string) (interface{}, error) {
+	if record, ok := e.records[path]; ok {
+		return record, nil
+	}
+
+	record, err := e.qp.dataRecord(path)
+	if err != nil {
+		return nil, err
+	}
+
+	e.records[path] = record
+	return record, nil
+}
+
+func (e *evaluator) eval(node Node) (interface{}, error) {
+	switch n := node.(type) {
+	case *literalNode:
+		return n.Value, nil
+
+	case *hostnameNode:
+		return e.qp.hostname, nil
+
+	case *tagNode:
+		value, ok := e.qp.tags[n.Key]
+		if !ok {
+			return nil, nil
+		}
+		return value, nil
+
+	case *dataPathNode:
+		record, err := e.record(n.Path)
+		if err != nil {
+			return nil, &Error{Pos: n.pos + 1, Message: err.Error()}
+		}
+
+		value, err := Select(record, n.Keys)
+		if err != nil {
+			return nil, newError(n.pos, "%v: %v", n.Path, err)
+		}
+		return value, nil
+
+	case *fieldNode:
+		value, err := Select(e.elements[len(e.elements)-1], n.Keys)
+		if err != nil {
+			// Elements of a collection do not always have the same fields.
+			return nil, nil
+		}
+		return value, nil
+
+	case *callNode:
+		return e.evalCall(n)
+
+	case *whereNode:
+		return e.evalWhere(n)
+
+	case *unaryNode:
+		return e.evalUnary(n)
+
+	case *binaryNode:
+		return e.evalBinary(n)
+	}
+
+	return nil, newError(node.Pos(), "unsupported expression")
+}
+
+func (e *evaluator) evalCall(n *callNode) (interface{}, error) {
+	if _, ok := historyFunctions[n.Name]; ok {
+		return e.evalHistoryCall(n)
+	}
+
+	args := make([]interface{}, len(n.Args))
+
+	for i, arg := range n.Args {
+		value, err := e.eval(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+
+	value, err := functions[n.Name](args)
+	if err != nil {
+		return nil, newError(n.pos, "%v: %v", n.Name, err)
+	}
+	return value, nil
+}
+
+// evalWhere keeps the elements of a list or an object for which the predicate is true.
+func (e *evaluator) evalWhere(n *whereNode) (interface{}, error) {
+	value, err := e.eval(n.List)
+	if err != nil {
+		return nil, err
+	}
+
+	var elements []interface{}
+
+	switch collection := value.(type) {
+	case []interface{}:
+		elements = collection
+
+	case map[string]interface{}:
+		elements, _ = selectAll(collection, nil, nil)
+
+	default:
+		return nil, newError(n.pos, "cannot filter %v, use * to select a collection", typeName(value))
+	}
+
+	results := make([]interface{}, 0)
+
+	for _, element := range elements {
+		e.elements = append(e.elements, element)
+		matched, err := e.eval(n.Predicate)
+		e.elements = e.elements[:len(e.elements)-1]
+
+		if err != nil {
+			return nil, err
+		}
+		if truthy(matched) {
+			results = append(results, element)
+		}
+	}
+
+	return results, nil
+}
+
+func (e *evaluator) evalUnary(n *unaryNode) (interface{}, error) {
+	operand, err := e.eval(n.Operand)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.Op == "!" {
+		return !truthy(operand), nil
+	}
+
+	number, ok := toNumber(operand)
+	if !ok {
+		return nil, newError(n.pos, "cannot negate %v", typeName(operand))
+	}
+	return -number, nil
+}
+
+func (e *evaluator) evalBinary(n *binaryNode) (interface{}, error) {
+	left, err := e.eval(n.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	// Short-circuit boolean operators.
+	switch n.Op {
+	case "&&":
+		if !truthy(left) {
+			return false, nil
+		}
+		right, err := e.eval(n.Right)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(right), nil
+
+	case "||":
+		if truthy(left) {
+			return true, nil
+		}
+		right, err := e.eval(n.Right)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(right), nil
+	}
+
+	right, err := e.eval(n.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.Op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return compare(n.pos, n.Op, left, right)
+	}
+
+	return arithmetic(n.pos, n.Op, left, right)
+}
+
+// compare compares numbers numerically, strings lexically, and everything else by equality only.
+// Ordering comparisons against null are false.
+func compare(pos int, op string, left, right interface{}) (bool, error) {
+	leftNumber, leftIsNumber := toNumber(left)
+	rightNumber, rightIsNumber := toNumber(right)
+
+	_, leftIsString := left.(string)
+	_, rightIsString := right.(string)
+
+	if leftIsNumber && rightIsNumber && !(leftIsString && rightIsString) {
+		switch op {
+		case "==":
+			return leftNumber == rightNumber, nil
+		case "!=":
+			return leftNumber != rightNumber, nil
+		case "<":
+			return leftNumber < rightNumber, nil
+		case "<=":
+			return leftNumber <= rightNumber, nil
+		case ">":
+			return leftNumber > rightNumber, nil
+		case ">=":
+			return leftNumber >= rightNumber, nil
+		}
+	}
+
+	if leftIsString && rightIsString {
+		leftString, rightString := left.(string), right.(string)
+
+		switch op {
+		case "==":
+			return leftString == rightString, nil
+		case "!=":
+			return leftString != rightString, nil
+		case "<":
+			return leftString < rightString, nil
+		case "<=":
+			return leftString <= rightString, nil
+		case ">":
+			return leftString > rightString, nil
+		case ">=":
+			return leftString >= rightString, nil
+		}
+	}
+
+	switch op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	}
+
+	// Missing values, e.g. max() of nothing, are never less or greater than anything.
+	if left == nil || right == nil {
+		return false, nil
+	}
+
+	return false, newError(pos, "cannot compare %v %v %v", typeName(left), op, typeName(right))
+}
+
+func equal(left, right interface{}) bool {
+	switch l := left.(type) {
+	case nil:
+		return right == nil
+	case bool:
+		r, ok := right.(bool)
+		return ok && l == r
+	case float64:
+		r, ok := right.(float64)
+		return ok && l == r
+	case string:
+		r, ok := right.(string)
+		return ok && l == r
+	}
+
+	leftJson, leftErr := json.Marshal(left)
+	rightJson, rightErr := json.Marshal(right)
+
+	return leftErr == nil && rightErr == nil && string(leftJson) == string(rightJson)
+}
+
+// arithmetic applies + - * / % on numbers. + also concatenates strings.
+func arithmetic(pos int, op string, left, right interface{}) (interface{}, error) {
+	if op == "+" {
+		leftString, leftIsString := left.(string)
+		rightString, rightIsString := right.(string)
+
+		if leftIsString && rightIsString {
+			return leftString + rightString, nil
+		}
+	}
+
+	leftNumber, leftOk := toNumber(left)
+	rightNumber, rightOk := toNumber(right)
+
+	if !leftOk || !rightOk {
+		return nil, newError(pos, "cannot apply %v on %v and %v", op, typeName(left), typeName(right))
+	}
+
+	switch op {
+	case "+":
+		return leftNumber + rightNumber, nil
+	case "-":
+		return leftNumber - rightNumber, nil
+	case "*":
+		return leftNumber * rightNumber, nil
+	case "/":
+		if rightNumber == 0 {
+			return nil, newError(pos, "division by zero")
+		}
+		return leftNumber / rightNumber, nil
+	case "%":
+		if rightNumber == 0 {
+			return nil, newError(pos, "division by zero")
+		}
+		return math.Mod(leftNumber, rightNumber), nil
+	}
+
+	return nil, newError(pos, "unknown operator %v", op)
+}
@@ -0,0 +1,51 @@
+package queryparser
+
+import (
+	"fmt"
+)
+
+// Filter keeps the elements of a list, or the entries of an object, for which predicate is true.
+// predicate is compiled by CompilePredicate.
+func (qp *QueryParser) Filter(collection interface{}, predicate Node) (interface{}, error) {
+	e := newEvaluator(qp)
+
+	matches := func(element interface{}) (bool, error) {
+		e.elements = append(e.elements, element)
+		matched, err := e.eval(predicate)
+		e.elements = e.elements[:len(e.elements)-1]
+
+		return truthy(matched), err
+	}
+
+	switch value := collection.(type) {
+	case []interface{}:
+		results := make([]interface{}, 0)
+
+		for _, element := range value {
+			matched, err := matches(element)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				results = append(results, element)
+			}
+		}
+		return results, nil
+
+	case map[string]interface{}:
+		results := make(map[string]interface{})
+
+		for key, element := range value {
+			matched, err := matches(element)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				results[key] = element
+			}
+		}
+		return results, nil
+	}
+
+	return nil, fmt.Errorf("cannot filter %v, only lists and objects can be filtered", typeName(collection))
+}
@@ -0,0 +1,180 @@
+package queryparser
+
+import (
+	"fmt"
+	"math"
+)
+
+// function receives evaluated arguments of a call.
+type function func(args []interface{}) (interface{}, error)
+
+// functions available in conditions, by name.
+var functions = map[string]function{
+	"max":   aggregate(math.Max),
+	"min":   aggregate(math.Min),
+	"sum":   sumFunction,
+	"avg":   avgFunction,
+	"count": countFunction,
+	"len":   lenFunction,
+	"any":   anyFunction,
+	"all":   allFunction,
+	"abs":   mathFunction(math.Abs),
+	"floor": mathFunction(math.Floor),
+	"ceil":  mathFunction(math.Ceil),
+	"round": roundFunction,
+}
+
+func isFunction(name string) bool {
+	_, ok := functions[name]
+	if !ok {
+		_, ok = historyFunctions[name]
+	}
+	return ok
+}
+
+// spread flattens list arguments, so max(/r/ps.*.Cpu) and max(1, 2) are equivalent.
+func spread(args []interface{}) []interface{} {
+	values := make([]interface{}, 0, len(args))
+
+	for _, arg := range args {
+		if list, ok := arg.([]interface{}); ok {
+			values = append(values, list...)
+		} else {
+			values = append(values, arg)
+		}
+	}
+
+	return values
+}
+
+// numbers spreads arguments and converts every value to number.
+func numbers(args []interface{}) ([]float64, error) {
+	values := spread(args)
+	numbers := make([]float64, len(values))
+
+	for i, value := range values {
+		number, ok := toNumber(value)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a number", typeName(value))
+		}
+		numbers[i] = number
+	}
+
+	return numbers, nil
+}
+
+// aggregate builds max and min. Both return null when there is nothing to aggregate.
+func aggregate(pick func(float64, float64) float64) function {
+	return func(args []interface{}) (interface{}, error) {
+		values, err := numbers(args)
+		if err != nil || len(values) == 0 {
+			return nil, err
+		}
+
+		result := values[0]
+		for _, value := range values[1:] {
+			result = pick(result, value)
+		}
+		return result, nil
+	}
+}
+
+func sumFunction(args []interface{}) (interface{}, error) {
+	values, err := numbers(args)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum, nil
+}
+
+// avgFunction returns null when there is nothing to average.
+func avgFunction(args []interface{}) (interface{}, error) {
+	values, err := numbers(args)
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+
+	sum, _ := sumFunction(args)
+	return sum.(float64) / float64(len(values)), nil
+}
+
+func countFunction(args []interface{}) (interface{}, error) {
+	return float64(len(spread(args))), nil
+}
+
+func lenFunction(args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expects 1 argument, got %v", len(args))
+	}
+
+	switch value := args[0].(type) {
+	case string:
+		return float64(len(value)), nil
+	case []interface{}:
+		return float64(len(value)), nil
+	case map[string]interface{}:
+		return float64(len(value)), nil
+	}
+
+	return nil, fmt.Errorf("cannot get length of %v", typeName(args[0]))
+}
+
+func anyFunction(args []interface{}) (interface{}, error) {
+	for _, value := range spread(args) {
+		if truthy(value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func allFunction(args []interface{}) (interface{}, error) {
+	for _, value := range spread(args) {
+		if !truthy(value) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func mathFunction(fn func(float64) float64) function {
+	return func(args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expects 1 argument, got %v", len(args))
+		}
+
+		number, ok := toNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("%v is not a number", typeName(args[0]))
+		}
+		return fn(number), nil
+	}
+}
+
+// roundFunction rounds half away from zero, to an optional number of decimal places.
+func roundFunction(args []interface{}) (interface{}, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, fmt.Errorf("expects 1 or 2 arguments, got %v", len(args))
+	}
+
+	number, ok := toNumber(args[0])
+	if !ok {
+		return nil, fmt.Errorf("%v is not a number", typeName(args[0]))
+	}
+
+	places := 0.0
+	if len(args) == 2 {
+		places, ok = toNumber(args[1])
+		if !ok {
+			return nil, fmt.Errorf("%v is not a number", typeName(args[1]))
+		}
+	}
+
+	shift := math.Pow(10, math.Trunc(places))
+	return math.Round(number*shift) / shift, nil
+}
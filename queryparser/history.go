@@ -0,0 +1,135 @@
+package queryparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/resourced/resourced/storage"
+)
+
+// IHistory provides previous records of data paths, it is implemented by storage.History.
+type IHistory interface {
+	Range(key string, from, to time.Time, limit int) []storage.Sample
+	Retention() time.Duration
+}
+
+// historyFunction receives the numeric values of a data path over a window, ordered from oldest to newest.
+type historyFunction func(samples []historySample) float64
+
+type historySample struct {
+	UnixNano int64
+	Value    float64
+}
+
+// historyFunctions need the data path itself rather than its current value.
+var historyFunctions = map[string]historyFunction{
+	"rate":  rateFunction,
+	"delta": deltaFunction,
+}
+
+// deltaFunction is the difference between the newest and the oldest value, for gauges.
+func deltaFunction(samples []historySample) float64 {
+	return samples[len(samples)-1].Value - samples[0].Value
+}
+
+// rateFunction is the per-second increase of a monotonic counter.
+// A decrease is treated as a counter reset, e.g. after a restart, and counts as an increase from 0.
+func rateFunction(samples []historySample) float64 {
+	increase := 0.0
+
+	for i := 1; i < len(samples); i++ {
+		if samples[i].Value >= samples[i-1].Value {
+			increase += samples[i].Value - samples[i-1].Value
+		} else {
+			increase += samples[i].Value
+		}
+	}
+
+	elapsed := time.Duration(samples[len(samples)-1].UnixNano - samples[0].UnixNano).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return increase / elapsed
+}
+
+// SetHistory enables rate() and delta().
+func (qp *QueryParser) SetHistory(history IHistory) {
+	qp.history = history
+}
+
+// evalHistoryCall evaluates rate(datapath, "window") and delta(datapath, "window").
+// It returns null when there are fewer than 2 samples in the window.
+func (e *evaluator) evalHistoryCall(n *callNode) (interface{}, error) {
+	if len(n.Args) != 2 {
+		return nil, newError(n.pos, "%v: expects 2 arguments, got %v", n.Name, len(n.Args))
+	}
+
+	path, ok := n.Args[0].(*dataPathNode)
+	if !ok {
+		return nil, newError(n.Args[0].Pos(), "%v: first argument must be a data path, e.g. /r/net-io.eth0.bytes_recv", n.Name)
+	}
+
+	windowValue, err := e.eval(n.Args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	windowString, ok := windowValue.(string)
+	if !ok {
+		return nil, newError(n.Args[1].Pos(), `%v: window must be a duration string, e.g. "5m"`, n.Name)
+	}
+
+	window, err := time.ParseDuration(windowString)
+	if err != nil || window <= 0 {
+		return nil, newError(n.Args[1].Pos(), "%v: invalid window %q", n.Name, windowString)
+	}
+
+	if e.qp.history == nil {
+		return nil, newError(n.pos, "%v: history is not available", n.Name)
+	}
+
+	// A longer window would silently be evaluated over less data.
+	if retention := e.qp.history.Retention(); retention > 0 && window > retention {
+		return nil, newError(n.Args[1].Pos(), "%v: window %v exceeds History.Duration %v", n.Name, windowString, retention)
+	}
+
+	samples := make([]historySample, 0)
+
+	for _, sample := range e.qp.history.Range(path.Path, time.Now().Add(-window), time.Time{}, 0) {
+		value, err := sampleValue(sample.Value, path.Keys)
+		if err != nil {
+			continue
+		}
+		samples = append(samples, historySample{UnixNano: sample.UnixNano, Value: value})
+	}
+
+	if len(samples) < 2 {
+		return nil, nil
+	}
+
+	return historyFunctions[n.Name](samples), nil
+}
+
+// sampleValue selects a number from the "Data" sub-structure of a recorded JSON.
+func sampleValue(recordJson []byte, keys []string) (float64, error) {
+	var record map[string]interface{}
+
+	err := json.Unmarshal(recordJson, &record)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := Select(record["Data"], keys)
+	if err != nil {
+		return 0, err
+	}
+
+	number, ok := toNumber(value)
+	if !ok {
+		return 0, fmt.Errorf("%v is not a number", typeName(value))
+	}
+
+	return number, nil
+}
@@ -0,0 +1,382 @@
+package queryparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenDataPath
+	tokenSelector
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokenEOF:
+		return "end of conditions"
+	case tokenNumber:
+		return "number"
+	case tokenString:
+		return "string"
+	case tokenIdent:
+		return "identifier"
+	case tokenDataPath:
+		return "data path"
+	case tokenSelector:
+		return "selector"
+	case tokenOperator:
+		return "operator"
+	case tokenLeftParen:
+		return `"("`
+	case tokenRightParen:
+		return `")"`
+	case tokenComma:
+		return `","`
+	}
+	return "token"
+}
+
+// token is a single lexeme of the conditions language.
+// For tokenSelector, Text is the key, e.g. `.UsePercent` and `["/tmp"]` become "UsePercent" and "/tmp".
+type token struct {
+	Kind  tokenKind
+	Text  string
+	Value interface{}
+	Pos   int
+}
+
+func (t token) String() string {
+	if t.Kind == tokenEOF {
+		return t.Kind.String()
+	}
+	return fmt.Sprintf("%v %q", t.Kind, t.Text)
+}
+
+// Error is a syntax or evaluation error, Pos is the 1-based character position in the conditions.
+type Error struct {
+	Pos     int
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Pos <= 0 {
+		return "Conditions: " + e.Message
+	}
+	return fmt.Sprintf("Conditions: %v at position %v", e.Message, e.Pos)
+}
+
+func newError(pos int, format string, args ...interface{}) *Error {
+	return &Error{Pos: pos + 1, Message: fmt.Sprintf(format, args...)}
+}
+
+// dataPathPrefixes are the beginnings of data paths, everything else starting with "/" is division.
+var dataPathPrefixes = []string{"/r/", "/w/", "/x/", "/logs/"}
+
+// twoCharOperators must be checked before single character operators.
+var twoCharOperators = []string{"==", "!=", "<=", ">=", "&&", "||"}
+
+const oneCharOperators = "<>!+-*/%"
+
+// isKeyChar reports if r can be part of a dotted selector key.
+// "/" and "-" are division and subtraction, e.g. LoadAvg1m-1, unless pathKey is true:
+// keys starting with "/" are paths and may contain them, e.g. /r/df./tmp.UsePercent.
+// Other keys with these characters have to use ["..."].
+func isKeyChar(r rune, pathKey bool) bool {
+	if unicode.IsSpace(r) {
+		return false
+	}
+	if !pathKey && (r == '/' || r == '-') {
+		return false
+	}
+	return !strings.ContainsRune(`.[](),"'=!<>&|+*%`, r)
+}
+
+func isDataPathChar(r rune) bool {
+	return r == '/' || r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// lex splits conditions into tokens. Whitespace is insignificant.
+func lex(input string) ([]token, error) {
+	l := &lexer{input: []rune(input)}
+	return l.run()
+}
+
+type lexer struct {
+	input  []rune
+	pos    int
+	tokens []token
+}
+
+func (l *lexer) peek(offset int) rune {
+	if l.pos+offset >= len(l.input) {
+		return 0
+	}
+	return l.input[l.pos+offset]
+}
+
+func (l *lexer) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(string(l.input[l.pos:]), prefix)
+}
+
+func (l *lexer) emit(kind tokenKind, start int, value interface{}) {
+	l.tokens = append(l.tokens, token{Kind: kind, Text: string(l.input[start:l.pos]), Value: value, Pos: start})
+}
+
+func (l *lexer) run() ([]token, error) {
+	for l.pos < len(l.input) {
+		r := l.peek(0)
+
+		switch {
+		case unicode.IsSpace(r):
+			l.pos++
+
+		case r == '(':
+			l.pos++
+			l.emit(tokenLeftParen, l.pos-1, nil)
+
+		case r == ')':
+			l.pos++
+			l.emit(tokenRightParen, l.pos-1, nil)
+
+		case r == ',':
+			l.pos++
+			l.emit(tokenComma, l.pos-1, nil)
+
+		case r == '"' || r == '\'':
+			start := l.pos
+			value, err := l.readString()
+			if err != nil {
+				return nil, err
+			}
+			l.emit(tokenString, start, value)
+
+		case unicode.IsDigit(r):
+			err := l.readNumber()
+			if err != nil {
+				return nil, err
+			}
+
+		case r == '.':
+			err := l.readDottedSelector()
+			if err != nil {
+				return nil, err
+			}
+
+		case r == '[':
+			err := l.readBracketSelector()
+			if err != nil {
+				return nil, err
+			}
+
+		case r == '/' && l.isDataPath():
+			start := l.pos
+			for l.pos < len(l.input) && isDataPathChar(l.peek(0)) {
+				l.pos++
+			}
+			l.emit(tokenDataPath, start, nil)
+
+		case isIdentStart(r):
+			start := l.pos
+			for l.pos < len(l.input) && isIdentChar(l.peek(0)) {
+				l.pos++
+			}
+			l.emit(tokenIdent, start, nil)
+
+		default:
+			if !l.readOperator() {
+				return nil, newError(l.pos, "unexpected character %q", string(r))
+			}
+		}
+	}
+
+	l.tokens = append(l.tokens, token{Kind: tokenEOF, Pos: l.pos})
+
+	return l.tokens, nil
+}
+
+func (l *lexer) isDataPath() bool {
+	for _, prefix := range dataPathPrefixes {
+		if l.hasPrefix(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *lexer) readOperator() bool {
+	start := l.pos
+
+	for _, op := range twoCharOperators {
+		if l.hasPrefix(op) {
+			l.pos += 2
+			l.emit(tokenOperator, start, nil)
+			return true
+		}
+	}
+
+	if strings.ContainsRune(oneCharOperators, l.peek(0)) {
+		l.pos++
+		l.emit(tokenOperator, start, nil)
+		return true
+	}
+
+	return false
+}
+
+// readString reads a single or double quoted string with backslash escapes.
+func (l *lexer) readString() (string, error) {
+	start := l.pos
+	quote := l.peek(0)
+	l.pos++
+
+	var value []rune
+
+	for l.pos < len(l.input) {
+		r := l.peek(0)
+		l.pos++
+
+		switch {
+		case r == quote:
+			return string(value), nil
+
+		case r == '\\':
+			if l.pos >= len(l.input) {
+				return "", newError(start, "unterminated string")
+			}
+			escaped := l.peek(0)
+			l.pos++
+
+			switch escaped {
+			case 'n':
+				value = append(value, '\n')
+			case 't':
+				value = append(value, '\t')
+			default:
+				value = append(value, escaped)
+			}
+
+		default:
+			value = append(value, r)
+		}
+	}
+
+	return "", newError(start, "unterminated string")
+}
+
+func (l *lexer) readNumber() error {
+	start := l.pos
+
+	for l.pos < len(l.input) && unicode.IsDigit(l.peek(0)) {
+		l.pos++
+	}
+	if l.peek(0) == '.' && unicode.IsDigit(l.peek(1)) {
+		l.pos++
+		for l.pos < len(l.input) && unicode.IsDigit(l.peek(0)) {
+			l.pos++
+		}
+	}
+	if (l.peek(0) == 'e' || l.peek(0) == 'E') && (unicode.IsDigit(l.peek(1)) || ((l.peek(1) == '+' || l.peek(1) == '-') && unicode.IsDigit(l.peek(2)))) {
+		l.pos += 2
+		for l.pos < len(l.input) && unicode.IsDigit(l.peek(0)) {
+			l.pos++
+		}
+	}
+
+	value, err := strconv.ParseFloat(string(l.input[start:l.pos]), 64)
+	if err != nil {
+		return newError(start, "invalid number %q", string(l.input[start:l.pos]))
+	}
+
+	l.emit(tokenNumber, start, value)
+	return nil
+}
+
+// readDottedSelector reads `.key`. A lone `*` is a wildcard key.
+func (l *lexer) readDottedSelector() error {
+	start := l.pos
+	l.pos++
+
+	if l.peek(0) == '*' {
+		l.pos++
+		l.tokens = append(l.tokens, token{Kind: tokenSelector, Text: "*", Pos: start})
+		return nil
+	}
+
+	keyStart := l.pos
+	pathKey := l.peek(0) == '/'
+	for l.pos < len(l.input) && isKeyChar(l.peek(0), pathKey) {
+		l.pos++
+	}
+	if l.pos == keyStart {
+		return newError(start, `expected key after "."`)
+	}
+
+	l.tokens = append(l.tokens, token{Kind: tokenSelector, Text: string(l.input[keyStart:l.pos]), Pos: start})
+	return nil
+}
+
+// readBracketSelector reads `["key"]`, `['key']`, `[0]` or `[*]`.
+func (l *lexer) readBracketSelector() error {
+	start := l.pos
+	l.pos++
+
+	for unicode.IsSpace(l.peek(0)) {
+		l.pos++
+	}
+
+	var key string
+
+	switch r := l.peek(0); {
+	case r == '"' || r == '\'':
+		value, err := l.readString()
+		if err != nil {
+			return err
+		}
+		key = value
+
+	case r == '*':
+		l.pos++
+		key = "*"
+
+	case unicode.IsDigit(r):
+		keyStart := l.pos
+		for l.pos < len(l.input) && unicode.IsDigit(l.peek(0)) {
+			l.pos++
+		}
+		key = string(l.input[keyStart:l.pos])
+
+	default:
+		return newError(start, `expected string, index, or "*" inside "[]"`)
+	}
+
+	for unicode.IsSpace(l.peek(0)) {
+		l.pos++
+	}
+	if l.peek(0) != ']' {
+		return newError(start, `missing "]"`)
+	}
+	l.pos++
+
+	l.tokens = append(l.tokens, token{Kind: tokenSelector, Text: key, Pos: start})
+	return nil
+}
@@ -0,0 +1,406 @@
+package queryparser
+
+// Grammar of the conditions language, from lowest to highest precedence:
+//
+//   expression     = or { "where" or }
+//   or             = and { "||" and }
+//   and            = not { "&&" not }
+//   not            = "!" not | comparison
+//   comparison     = additive [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) additive ]
+//   additive       = multiplicative { ( "+" | "-" ) multiplicative }
+//   multiplicative = unary { ( "*" | "/" | "%" ) unary }
+//   unary          = "-" unary | primary
+//   primary        = number | string | "true" | "false" | "null" | "name"
+//                  | "tags" selector | datapath { selector } | "(" expression ")"
+//                  | function "(" [ expression { "," expression } ] ")"
+//                  | field { selector }
+//
+// A selector of "*" selects every element of an object or array, producing a list.
+// Inside the right side of "where", identifiers are fields of the element being filtered,
+// and "it" is the element itself.
+
+// Node is an element of a parsed conditions tree.
+type Node interface {
+	Pos() int
+}
+
+type literalNode struct {
+	pos   int
+	Value interface{}
+}
+
+type dataPathNode struct {
+	pos  int
+	Path string
+	Keys []string
+}
+
+type tagNode struct {
+	pos int
+	Key string
+}
+
+type hostnameNode struct {
+	pos int
+}
+
+type callNode struct {
+	pos  int
+	Name string
+	Args []Node
+}
+
+type whereNode struct {
+	pos       int
+	List      Node
+	Predicate Node
+}
+
+// fieldNode selects Keys from the element being filtered by "where".
+type fieldNode struct {
+	pos  int
+	Keys []string
+}
+
+type unaryNode struct {
+	pos     int
+	Op      string
+	Operand Node
+}
+
+type binaryNode struct {
+	pos   int
+	Op    string
+	Left  Node
+	Right Node
+}
+
+func (n *literalNode) Pos() int  { return n.pos }
+func (n *dataPathNode) Pos() int { return n.pos }
+func (n *tagNode) Pos() int      { return n.pos }
+func (n *hostnameNode) Pos() int { return n.pos }
+func (n *callNode) Pos() int     { return n.pos }
+func (n *whereNode) Pos() int    { return n.pos }
+func (n *fieldNode) Pos() int    { return n.pos }
+func (n *unaryNode) Pos() int    { return n.pos }
+func (n *binaryNode) Pos() int   { return n.pos }
+
+// Compile parses conditions into a tree without evaluating it.
+// It is useful to validate conditions before any reader data is available.
+func Compile(conditions string) (Node, error) {
+	return compile(conditions, 0)
+}
+
+// CompilePredicate parses a predicate evaluated against each element of a collection, see Filter.
+// Like the right side of "where", bare identifiers are fields of the element and it is the element itself.
+func CompilePredicate(predicate string) (Node, error) {
+	return compile(predicate, 1)
+}
+
+func compile(conditions string, whereDepth int) (Node, error) {
+	tokens, err := lex(conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, whereDepth: whereDepth}
+
+	if p.peek().Kind == tokenEOF {
+		return nil, newError(0, "conditions are empty")
+	}
+
+	node, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().Kind != tokenEOF {
+		return nil, p.unexpected()
+	}
+
+	return node, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+
+	// whereDepth is greater than 0 while parsing the right side of "where".
+	whereDepth int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.Kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isOperator(ops ...string) bool {
+	t := p.peek()
+	if t.Kind != tokenOperator {
+		return false
+	}
+	for _, op := range ops {
+		if t.Text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) unexpected() error {
+	t := p.peek()
+	if t.Kind == tokenEOF {
+		return newError(t.Pos, "unexpected end of conditions")
+	}
+	return newError(t.Pos, "unexpected %v", t)
+}
+
+func (p *parser) expect(kind tokenKind) (token, error) {
+	if p.peek().Kind != kind {
+		t := p.peek()
+		if t.Kind == tokenEOF {
+			return t, newError(t.Pos, "expected %v but conditions ended", kind)
+		}
+		return t, newError(t.Pos, "expected %v but found %v", kind, t)
+	}
+	return p.next(), nil
+}
+
+// parseBinary parses left-associative binary operators of the same precedence.
+func (p *parser) parseBinary(operand func() (Node, error), ops ...string) (Node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOperator(ops...) {
+		op := p.next()
+
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &binaryNode{pos: op.Pos, Op: op.Text, Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.Kind == tokenIdent && t.Text == keyword
+}
+
+func (p *parser) parseExpression() (Node, error) {
+	left, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("where") {
+		keyword := p.next()
+
+		p.whereDepth++
+		predicate, err := p.parseOr()
+		p.whereDepth--
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = &whereNode{pos: keyword.Pos, List: left, Predicate: predicate}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseOr() (Node, error) {
+	return p.parseBinary(p.parseAnd, "||")
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	return p.parseBinary(p.parseNot, "&&")
+}
+
+func (p *parser) parseNot() (Node, error) {
+	if p.isOperator("!") {
+		op := p.next()
+
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{pos: op.Pos, Op: "!", Operand: operand}, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.isOperator("==", "!=", "<", "<=", ">", ">=") {
+		op := p.next()
+
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &binaryNode{pos: op.Pos, Op: op.Text, Left: left, Right: right}
+
+		if p.isOperator("==", "!=", "<", "<=", ">", ">=") {
+			return nil, newError(p.peek().Pos, "comparisons cannot be chained, use && instead")
+		}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (Node, error) {
+	return p.parseBinary(p.parseMultiplicative, "+", "-")
+}
+
+func (p *parser) parseMultiplicative() (Node, error) {
+	return p.parseBinary(p.parseUnary, "*", "/", "%")
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	if p.isOperator("-") {
+		op := p.next()
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{pos: op.Pos, Op: "-", Operand: operand}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parseSelectors() []string {
+	keys := make([]string, 0)
+	for p.peek().Kind == tokenSelector {
+		keys = append(keys, p.next().Text)
+	}
+	return keys
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	t := p.peek()
+
+	switch t.Kind {
+	case tokenNumber, tokenString:
+		p.next()
+		return &literalNode{pos: t.Pos, Value: t.Value}, nil
+
+	case tokenLeftParen:
+		p.next()
+
+		node, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = p.expect(tokenRightParen)
+		if err != nil {
+			return nil, err
+		}
+		return node, nil
+
+	case tokenDataPath:
+		p.next()
+		return &dataPathNode{pos: t.Pos, Path: t.Text, Keys: p.parseSelectors()}, nil
+
+	case tokenIdent:
+		return p.parseIdent()
+	}
+
+	return nil, p.unexpected()
+}
+
+func (p *parser) parseCall(name token) (Node, error) {
+	if !isFunction(name.Text) {
+		return nil, newError(name.Pos, "unknown function %v", name.Text)
+	}
+
+	p.next() // Left parenthesis.
+
+	call := &callNode{pos: name.Pos, Name: name.Text, Args: make([]Node, 0)}
+
+	if p.peek().Kind == tokenRightParen {
+		p.next()
+		return call, nil
+	}
+
+	for {
+		arg, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, arg)
+
+		if p.peek().Kind != tokenComma {
+			break
+		}
+		p.next()
+	}
+
+	_, err := p.expect(tokenRightParen)
+	if err != nil {
+		return nil, err
+	}
+
+	return call, nil
+}
+
+func (p *parser) parseIdent() (Node, error) {
+	t := p.next()
+
+	if p.peek().Kind == tokenLeftParen {
+		return p.parseCall(t)
+	}
+
+	switch t.Text {
+	case "true":
+		return &literalNode{pos: t.Pos, Value: true}, nil
+
+	case "false":
+		return &literalNode{pos: t.Pos, Value: false}, nil
+
+	case "null":
+		return &literalNode{pos: t.Pos, Value: nil}, nil
+
+	case "name":
+		return &hostnameNode{pos: t.Pos}, nil
+
+	case "tags":
+		keys := p.parseSelectors()
+		if len(keys) != 1 {
+			return nil, newError(t.Pos, `tags must be followed by exactly one key, e.g. tags.role or tags["role"]`)
+		}
+		return &tagNode{pos: t.Pos, Key: keys[0]}, nil
+	}
+
+	if p.whereDepth > 0 {
+		keys := make([]string, 0)
+		if t.Text != "it" {
+			keys = append(keys, t.Text)
+		}
+		return &fieldNode{pos: t.Pos, Keys: append(keys, p.parseSelectors()...)}, nil
+	}
+
+	return nil, newError(t.Pos, "unknown identifier %q", t.Text)
+}
@@ -1,15 +1,12 @@
+// Package queryparser parses and evaluates executor Conditions.
 package queryparser
 
 import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"strings"
 	"sync"
 
-	"github.com/jmoiron/jsonq"
-	"github.com/robertkrimen/otto"
-
 	"github.com/resourced/resourced/libmap"
 )
 
@@ -29,6 +26,7 @@ type QueryParser struct {
 	hostname string
 	tags     map[string]string
 	data     *libmap.TSafeMapBytes
+	history  IHistory
 	sync.RWMutex
 }
 
@@ -36,76 +34,34 @@ func (qp *QueryParser) SetTags(tags map[string]string) {
 	qp.tags = tags
 }
 
+// Parse compiles and evaluates the query. The result is converted to boolean.
+// Syntax errors and references to missing data are returned as *Error.
 func (qp *QueryParser) Parse(query string) (bool, error) {
-	query, err := qp.replaceDataPathWithValue(query)
-	if err != nil {
-		return false, err
-	}
-
-	query, err = qp.replaceTagsWithValue(query)
+	node, err := Compile(query)
 	if err != nil {
 		return false, err
 	}
 
-	query, err = qp.replaceHostnameWithValue(query)
+	value, err := qp.Eval(node)
 	if err != nil {
 		return false, err
 	}
 
-	value, err := otto.New().Run(query)
-	if err != nil {
-		return false, err
-	}
-
-	return value.ToBoolean()
+	return truthy(value), nil
 }
 
-func (qp *QueryParser) replaceHostnameWithValue(query string) (string, error) {
-	queryChunks := strings.Fields(query)
-
-	for i, chunk := range queryChunks {
-		if strings.HasSuffix(chunk, "name") {
-			openParensCount := strings.Count(chunk, "(")
-
-			if openParensCount == 0 {
-				queryChunks[i] = fmt.Sprintf(`"%v"`, qp.hostname)
-
-			} else {
-				queryChunks[i] = strings.Repeat("(", openParensCount) + fmt.Sprintf(`"%v"`, qp.hostname)
-			}
-		}
-	}
-
-	return strings.Join(queryChunks, " "), nil
+// Eval evaluates a compiled query and returns its raw value.
+func (qp *QueryParser) Eval(node Node) (interface{}, error) {
+	return newEvaluator(qp).eval(node)
 }
 
-func (qp *QueryParser) replaceTagsWithValue(query string) (string, error) {
-	queryChunks := strings.Fields(query)
-
-	for i, chunk := range queryChunks {
-		if strings.Contains(chunk, "tags.") {
-			openParensCount := strings.Count(chunk, "(")
-			chunk = strings.Replace(chunk, "(", "", -1)
-
-			prefixAndTagChunk := strings.Split(chunk, ".")
-
-			tag := prefixAndTagChunk[1]
-			value := qp.tags[tag]
-
-			if openParensCount == 0 {
-				queryChunks[i] = fmt.Sprintf(`"%v"`, value)
-
-			} else {
-				queryChunks[i] = strings.Repeat("(", openParensCount) + fmt.Sprintf(`"%v"`, value)
-			}
-		}
+// dataRecord returns the "Data" sub-structure of the JSON stored under datapath.
+func (qp *QueryParser) dataRecord(datapath string) (interface{}, error) {
+	dataJsonBytes := qp.data.Get(datapath)
+	if dataJsonBytes == nil {
+		return nil, fmt.Errorf("%v does not exist", datapath)
 	}
 
-	return strings.Join(queryChunks, " "), nil
-}
-
-func (qp *QueryParser) dataValue(datapath, jsonSelector string) (interface{}, error) {
-	dataJsonBytes := qp.data.Get(datapath)
 	var dataJson map[string]interface{}
 
 	err := json.Unmarshal(dataJsonBytes, &dataJson)
@@ -113,46 +69,20 @@ func (qp *QueryParser) dataValue(datapath, jsonSelector string) (interface{}, er
 		return nil, err
 	}
 
-	jq := jsonq.NewQuery(dataJson)
-
-	jsonSelectorChunks := strings.Split(jsonSelector, ".")
-	jsonSelectorChunks = append([]string{"Data"}, jsonSelectorChunks...) // Always query from "Data" sub-structure.
-
-	return jq.Interface(jsonSelectorChunks...)
+	return dataJson["Data"], nil
 }
 
-func (qp *QueryParser) replaceDataPathWithValue(query string) (string, error) {
-	queryChunks := strings.Fields(query)
-
-	for i, chunk := range queryChunks {
-		if strings.Contains(chunk, "/r/") || strings.Contains(chunk, "/w/") || strings.Contains(chunk, "/x/") {
-			openParensCount := strings.Count(chunk, "(")
-			chunk = strings.Replace(chunk, "(", "", -1)
-
-			dataPathAndJsonSelectorChunks := strings.Split(chunk, ".")
-
-			dataPath := dataPathAndJsonSelectorChunks[0]
-			jsonSelectorChunks := dataPathAndJsonSelectorChunks[1:]
-			jsonSelector := strings.Join(jsonSelectorChunks, ".")
-
-			valueInterface, err := qp.dataValue(dataPath, jsonSelector)
-			if err != nil {
-				return "", err
-			}
-
-			valueBytes, err := json.Marshal(valueInterface)
-			if err != nil {
-				return "", err
-			}
-
-			if openParensCount == 0 {
-				queryChunks[i] = string(valueBytes)
+// dataValue returns the value of jsonSelector, always queried from "Data" sub-structure of datapath.
+func (qp *QueryParser) dataValue(datapath, jsonSelector string) (interface{}, error) {
+	keys, err := ParseSelector(jsonSelector)
+	if err != nil {
+		return nil, err
+	}
 
-			} else {
-				queryChunks[i] = strings.Repeat("(", openParensCount) + string(valueBytes)
-			}
-		}
+	data, err := qp.dataRecord(datapath)
+	if err != nil {
+		return nil, err
 	}
 
-	return strings.Join(queryChunks, " "), nil
+	return Select(data, keys)
 }
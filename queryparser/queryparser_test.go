@@ -1,8 +1,12 @@
 package queryparser
 
 import (
+	"fmt"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/resourced/resourced/storage"
 )
 
 var queries = []string{
@@ -33,10 +37,12 @@ var metadataQueries = []string{
 func queryparserForTest(t *testing.T) *QueryParser {
 	data := make(map[string][]byte)
 	data["/r/load-avg"] = []byte(`{"Data": {"LoadAvg1m": 0.904296875}}`)
+	data["/r/df"] = []byte(`{"Data": {"/tmp": {"UsePercent": 92.5, "Device name": "tmpfs"}, "/": {"UsePercent": "40"}}}`)
 
 	tags := make(map[string]string)
 	tags["role"] = "appserver"
 	tags["environment"] = "staging"
+	tags["username"] = "didip"
 
 	qp := New(data, tags)
 
@@ -58,44 +64,6 @@ func TestDataValue(t *testing.T) {
 	}
 }
 
-func TestReplaceDataPathWithValue(t *testing.T) {
-	qp := queryparserForTest(t)
-
-	query, err := qp.replaceDataPathWithValue(queries[0])
-	if err != nil {
-		t.Fatalf("Failed to replace data path with value. Error: %v", err)
-	}
-
-	if strings.Contains(query, "/r/") {
-		t.Fatalf("Failed to replace data path with value. Query: %v", query)
-	}
-}
-
-func TestReplaceTagsWithValue(t *testing.T) {
-	qp := queryparserForTest(t)
-
-	query, err := qp.replaceTagsWithValue(tagQueries[0])
-	if err != nil {
-		t.Fatalf("Failed to replace tags with value. Error: %v", err)
-	}
-
-	if strings.Contains(query, "tags.") {
-		t.Fatalf("Failed to replace tags with value. Query: %v", query)
-	}
-}
-
-func TestReplaceHostnameWithValue(t *testing.T) {
-	qp := queryparserForTest(t)
-
-	query, err := qp.replaceHostnameWithValue(nameQueries[0])
-	if err != nil {
-		t.Fatalf("Failed to replace tags with value. Error: %v", err)
-	}
-	if strings.Contains(query, "name ==") {
-		t.Fatalf("Failed to replace tags with value. Query: %v", query)
-	}
-}
-
 func TestParseQueries(t *testing.T) {
 	qp := queryparserForTest(t)
 
@@ -149,3 +117,208 @@ func TestParseHostnameQueries(t *testing.T) {
 		}
 	}
 }
+
+func TestDataValueWithBracketSelector(t *testing.T) {
+	qp := queryparserForTest(t)
+
+	valueInterface, err := qp.dataValue("/r/df", `["/tmp"].UsePercent`)
+	if err != nil {
+		t.Fatalf("Unable to fetch data value. Error: %v", err)
+	}
+	if valueInterface.(float64) != 92.5 {
+		t.Fatalf("Fetch data value incorrectly. Value: %v", valueInterface)
+	}
+}
+
+func TestParseExpressions(t *testing.T) {
+	qp := queryparserForTest(t)
+
+	expectations := map[string]bool{
+		`(/r/load-avg.LoadAvg1m>0.5)&&(/r/load-avg.LoadAvg1m<10)`:   true,
+		`/r/df["/tmp"].UsePercent > 90`:                             true,
+		`/r/df["/tmp"]["Device name"] == "tmpfs"`:                   true,
+		`/r/df["/"].UsePercent < 50`:                                true,
+		`/r/df["/"].UsePercent / 2 == 20`:                           true,
+		`tags.username == "didip"`:                                  true,
+		`tags.role == 'appserver' && !(tags.environment == "prod")`: true,
+		`tags.doesnotexist == null`:                                 true,
+		`name != "" && 1 + 2 * 3 == 7`:                              true,
+		`-(1 - 3) % 2 == 0 || false`:                                true,
+		`"abc" < "abd" && !("10" > "9")`:                            true,
+		`"10" > 9 && "10" < "9"`:                                    true,
+		`/r/load-avg.LoadAvg1m >= 1`:                                false,
+		`/r/load-avg.LoadAvg1m-1 < 0`:                               true,
+		`/r/load-avg.LoadAvg1m/2 < 0.5`:                             true,
+		`/r/df./tmp.UsePercent-2 > 90`:                              true,
+	}
+
+	for query, expected := range expectations {
+		result, err := qp.Parse(query)
+		if err != nil {
+			t.Fatalf("Failed to parse query: %v. Error: %v", query, err)
+		}
+		if result != expected {
+			t.Fatalf("Failed to parse query correctly: %v. Result: %v", query, result)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	qp := queryparserForTest(t)
+
+	expectations := map[string]int{
+		`/r/load-avg.LoadAvg1m >`:                24,
+		`(/r/load-avg.LoadAvg1m > 1`:             27,
+		`/r/load-avg.LoadAvg1m > 1 > 2`:          27,
+		`tags.role == "appserver`:                14,
+		`hostname == "x"`:                        1,
+		`/r/load-avg.LoadAvg1m # 1`:              23,
+		`/r/does-not-exist.Value > 1`:            1,
+		`/r/load-avg.DoesNotExist > 1`:           1,
+		`1 / 0`:                                  3,
+		`tags.role > 1 && /r/load-avg.LoadAvg1m`: 11,
+	}
+
+	for query, position := range expectations {
+		_, err := qp.Parse(query)
+		if err == nil {
+			t.Fatalf("Parsing invalid query should fail: %v", query)
+		}
+
+		conditionsErr, ok := err.(*Error)
+		if !ok {
+			t.Fatalf("Error should be *Error: %v. Error: %v", query, err)
+		}
+		if conditionsErr.Pos != position {
+			t.Fatalf("Error position is incorrect: %v. Error: %v", query, err)
+		}
+	}
+}
+
+func TestParseFunctions(t *testing.T) {
+	data := make(map[string][]byte)
+	data["/r/docker-containers-memory"] = []byte(`{"Data": {"web": {"rss": 2e9}, "db": {"rss": 5e8}, "cron": {"cache": 1}}}`)
+	data["/r/ps"] = []byte(`{"Data": {"1": {"State": "Z", "Cpu": "1.5"}, "2": {"State": "S", "Cpu": "0.5"}, "3": {"State": "Z", "Cpu": "3"}}}`)
+	data["/r/net"] = []byte(`{"Data": {"Interfaces": [{"Name": "eth0", "Up": true}, {"Name": "lo", "Up": false}]}}`)
+
+	qp := New(data, nil)
+
+	expectations := map[string]bool{
+		`max(/r/docker-containers-memory.*.rss) > 1e9`:                                 true,
+		`min(/r/docker-containers-memory.*.rss) == 5e8`:                                true,
+		`sum(/r/docker-containers-memory.*.rss) == 2.5e9`:                              true,
+		`avg(/r/docker-containers-memory.*.rss) == 1.25e9`:                             true,
+		`count(/r/docker-containers-memory.*.rss) == 2`:                                true,
+		`len(/r/docker-containers-memory) == 3`:                                        true,
+		`count(/r/ps.* where State == "Z") > 1`:                                        true,
+		`count(/r/ps.* where State == "Z" && Cpu > 2) == 1`:                            true,
+		`sum(/r/ps.*.Cpu) == 5`:                                                        true,
+		`count(/r/ps where it.State == "S") == 1`:                                      true,
+		`any(/r/net.Interfaces[*].Up) && !all(/r/net.Interfaces[*].Up)`:                true,
+		`len(/r/net.Interfaces.* where Name == "eth0") == 1`:                           true,
+		`max(/r/ps.* where State == "X") > 0`:                                          false,
+		`abs(-2) == 2 && floor(1.5) == 1 && ceil(1.5) == 2 && round(2.345, 2) == 2.35`: true,
+		`max(1, 2, 3) == 3 && len("abc") == 3`:                                         true,
+	}
+
+	for query, expected := range expectations {
+		result, err := qp.Parse(query)
+		if err != nil {
+			t.Fatalf("Failed to parse query: %v. Error: %v", query, err)
+		}
+		if result != expected {
+			t.Fatalf("Failed to parse query correctly: %v. Result: %v", query, result)
+		}
+	}
+
+	for _, query := range []string{`median(1) > 0`, `State == "Z"`, `sum(/r/ps.*.State) > 0`, `len(1, 2) > 0`} {
+		_, err := qp.Parse(query)
+		if err == nil {
+			t.Fatalf("Parsing invalid query should fail: %v", query)
+		}
+	}
+}
+
+func TestParseHistoryFunctions(t *testing.T) {
+	history := storage.NewHistory(10, 0)
+
+	now := time.Now()
+	for i, bytesRecv := range []int{1000, 1600, 100, 700} {
+		unixNano := now.Add(time.Duration(i-3) * time.Minute).UnixNano()
+		history.Add("/r/net-io", unixNano, []byte(fmt.Sprintf(`{"Data": {"eth0": {"bytes_recv": %v}}}`, bytesRecv)))
+	}
+
+	qp := New(nil, nil)
+	qp.SetHistory(history)
+
+	expectations := map[string]bool{
+		// 600 + 100 (reset) + 600 bytes in 3 minutes.
+		`rate(/r/net-io.eth0.bytes_recv, "5m") == 1300 / 180`: true,
+		`delta(/r/net-io.eth0.bytes_recv, "5m") == -300`:      true,
+		`delta(/r/net-io.eth0.bytes_recv, "90s") == 600`:      true,
+		`rate(/r/net-io.eth0.bytes_recv, "30s") > 0`:          false,
+		`rate(/r/net-io.eth0.bytes_recv, "30s") == null`:      true,
+	}
+
+	for query, expected := range expectations {
+		result, err := qp.Parse(query)
+		if err != nil {
+			t.Fatalf("Failed to parse query: %v. Error: %v", query, err)
+		}
+		if result != expected {
+			t.Fatalf("Failed to parse query correctly: %v. Result: %v", query, result)
+		}
+	}
+
+	for _, query := range []string{`rate(1, "5m") > 0`, `rate(/r/net-io.eth0.bytes_recv) > 0`, `delta(/r/net-io.eth0.bytes_recv, "soon") > 0`} {
+		_, err := qp.Parse(query)
+		if err == nil {
+			t.Fatalf("Parsing invalid query should fail: %v", query)
+		}
+	}
+
+	qp.SetHistory(storage.NewHistory(10, 15*time.Minute))
+
+	_, err := qp.Parse(`rate(/r/net-io.eth0.bytes_recv, "1h") > 0`)
+	if err == nil || !strings.Contains(err.Error(), "History.Duration") {
+		t.Fatalf("Window longer than History.Duration should fail. Error: %v", err)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	qp := New(nil, nil)
+
+	predicate, err := CompilePredicate(`State == "Z" && Cpu > 1`)
+	if err != nil {
+		t.Fatalf("Compiling predicate should work. Error: %v", err)
+	}
+
+	processes := map[string]interface{}{
+		"1": map[string]interface{}{"State": "Z", "Cpu": "1.5"},
+		"2": map[string]interface{}{"State": "S", "Cpu": "3"},
+		"3": map[string]interface{}{"State": "Z", "Cpu": 0.5},
+	}
+
+	filtered, err := qp.Filter(processes, predicate)
+	if err != nil {
+		t.Fatalf("Filtering object should work. Error: %v", err)
+	}
+	if len(filtered.(map[string]interface{})) != 1 || filtered.(map[string]interface{})["1"] == nil {
+		t.Fatalf("Filtering object should keep matching entries by key. Filtered: %v", filtered)
+	}
+
+	predicate, _ = CompilePredicate(`it > 1`)
+
+	filtered, err = qp.Filter([]interface{}{0.5, 2.0, 3.0}, predicate)
+	if err != nil {
+		t.Fatalf("Filtering list should work. Error: %v", err)
+	}
+	if len(filtered.([]interface{})) != 2 {
+		t.Fatalf("Filtering list should keep matching elements. Filtered: %v", filtered)
+	}
+
+	_, err = qp.Filter(1.5, predicate)
+	if err == nil {
+		t.Fatalf("Filtering a number should fail.")
+	}
+}
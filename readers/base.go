@@ -3,14 +3,17 @@ package readers
 
 import (
 	"errors"
+	"sort"
+
 	resourced_config "github.com/resourced/resourced/config"
-	"reflect"
 )
 
 var readerConstructors = make(map[string]func() IReader)
+var readerDescriptions = make(map[string]string)
 
 // Register makes any reader constructor available by name.
-func Register(name string, constructor func() IReader) {
+// description tells what the reader does, it is shown by GET /gostructs and resourced list-gostructs.
+func Register(name string, constructor func() IReader, description string) {
 	if constructor == nil {
 		panic("reader: Register reader constructor is nil")
 	}
@@ -18,6 +21,12 @@ func Register(name string, constructor func() IReader) {
 		panic("reader: Register called twice for reader constructor " + name)
 	}
 	readerConstructors[name] = constructor
+	readerDescriptions[name] = description
+}
+
+// Description returns the description of a registered reader constructor.
+func Description(name string) string {
+	return readerDescriptions[name]
 }
 
 // NewGoStruct instantiates IReader
@@ -30,6 +39,16 @@ func NewGoStruct(name string) (IReader, error) {
 	return constructor(), nil
 }
 
+// Names returns names of all registered reader constructors, sorted.
+func Names() []string {
+	names := make([]string, 0, len(readerConstructors))
+	for name := range readerConstructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // NewGoStructByConfig instantiates IReader given Config struct
 func NewGoStructByConfig(config resourced_config.Config) (IReader, error) {
 	reader, err := NewGoStruct(config.GoStruct)
@@ -37,16 +56,10 @@ func NewGoStructByConfig(config resourced_config.Config) (IReader, error) {
 		return nil, err
 	}
 
-	// Populate IReader fields dynamically
-	if len(config.GoStructFields) > 0 {
-		for structFieldInString, value := range config.GoStructFields {
-			goStructField := reflect.ValueOf(reader).Elem().FieldByName(structFieldInString)
-
-			if goStructField.IsValid() && goStructField.CanSet() {
-				valueOfValue := reflect.ValueOf(value)
-				goStructField.Set(valueOfValue)
-			}
-		}
+	// Populate IReader fields, converting values to the types of its fields
+	err = resourced_config.DecodeGoStructFields(config.GoStructFields, reader)
+	if err != nil {
+		return nil, err
 	}
 
 	return reader, err
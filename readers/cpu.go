@@ -7,7 +7,7 @@ import (
 )
 
 func init() {
-	Register("CpuInfo", NewCpuInfo)
+	Register("CpuInfo", NewCpuInfo, "CPU information of each core.")
 }
 
 // NewCpuInfo is CpuInfo constructor.
@@ -10,7 +10,7 @@ import (
 )
 
 func init() {
-	Register("Df", NewDf)
+	Register("Df", NewDf, "Disk usage of mounted filesystems, like df.")
 }
 
 // NewDf is Df constructor.
@@ -24,7 +24,7 @@ func NewDf() IReader {
 // Data source: https://github.com/cloudfoundry/gosigar/tree/master
 type Df struct {
 	Data    map[string]map[string]interface{}
-	FSPaths string
+	FSPaths string `description:"Comma separated paths to report on top of every partition." example:"/,/tmp"`
 }
 
 func (d *Df) buildData(path string) error {
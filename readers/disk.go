@@ -7,8 +7,8 @@ import (
 )
 
 func init() {
-	Register("DiskPartitions", NewDiskPartitions)
-	Register("DiskIO", NewDiskIO)
+	Register("DiskPartitions", NewDiskPartitions, "Disk partitions.")
+	Register("DiskIO", NewDiskIO, "I/O counters of each disk.")
 }
 
 // NewDiskPartitions is DiskPartitions constructor.
@@ -7,7 +7,7 @@ import (
 )
 
 func init() {
-	Register("DMI", NewDMI)
+	Register("DMI", NewDMI, "DMI table of the host, from dmidecode.")
 }
 
 func NewDMI() IReader {
@@ -7,7 +7,7 @@ import (
 )
 
 func init() {
-	readers.Register("DockerContainers", NewDockerContainers)
+	readers.Register("DockerContainers", NewDockerContainers, "Inspected Docker containers.")
 }
 
 // NewDockerContainers is DockerContainers constructor.
@@ -20,7 +20,7 @@ func NewDockerContainers() readers.IReader {
 // DockerContainers gathers docker containers data.
 type DockerContainers struct {
 	Data       map[string]*libdocker.CompleteDockerContainer
-	DockerHost string
+	DockerHost string `description:"Docker daemon address, DOCKER_HOST is used when empty." example:"unix:///var/run/docker.sock"`
 }
 
 func (dc *DockerContainers) Run() error {
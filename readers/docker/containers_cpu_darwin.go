@@ -8,7 +8,7 @@ import (
 )
 
 func init() {
-	readers.Register("DockerContainersCpu", NewDockerContainersCpu)
+	readers.Register("DockerContainersCpu", NewDockerContainersCpu, "CPU usage of each Docker container, read from cgroups.")
 }
 
 func NewDockerContainersCpu() readers.IReader {
@@ -19,7 +19,7 @@ func NewDockerContainersCpu() readers.IReader {
 
 type DockerContainersCpu struct {
 	Data           map[string]string
-	CgroupBasePath string
+	CgroupBasePath string `description:"Cgroup directory of Docker containers." example:"/sys/fs/cgroup/memory/docker"`
 }
 
 func (m *DockerContainersCpu) Run() error {
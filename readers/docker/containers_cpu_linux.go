@@ -12,7 +12,7 @@ import (
 )
 
 func init() {
-	readers.Register("DockerContainersCpu", NewDockerContainersCpu)
+	readers.Register("DockerContainersCpu", NewDockerContainersCpu, "CPU usage of each Docker container, read from cgroups.")
 }
 
 func NewDockerContainersCpu() readers.IReader {
@@ -27,8 +27,8 @@ func NewDockerContainersCpu() readers.IReader {
 // * https://github.com/shirou/gopsutil/blob/master/docker/docker_linux.go
 type DockerContainersCpu struct {
 	Data           map[string]*gopsutil_cpu.TimesStat
-	DockerHost     string
-	CgroupBasePath string
+	DockerHost     string `description:"Docker daemon address, DOCKER_HOST is used when empty." example:"unix:///var/run/docker.sock"`
+	CgroupBasePath string `description:"Cgroup directory of Docker containers." example:"/sys/fs/cgroup/memory/docker"`
 }
 
 // Run gathers cgroup CPU information from cgroup itself.
@@ -8,7 +8,7 @@ import (
 )
 
 func init() {
-	readers.Register("DockerContainersMemory", NewDockerContainersMemory)
+	readers.Register("DockerContainersMemory", NewDockerContainersMemory, "Memory usage of each Docker container, read from cgroups.")
 }
 
 func NewDockerContainersMemory() readers.IReader {
@@ -19,8 +19,8 @@ func NewDockerContainersMemory() readers.IReader {
 
 type DockerContainersMemory struct {
 	Data           map[string]string
-	DockerHost     string
-	CgroupBasePath string
+	DockerHost     string `description:"Docker daemon address, DOCKER_HOST is used when empty." example:"unix:///var/run/docker.sock"`
+	CgroupBasePath string `description:"Cgroup directory of Docker containers." example:"/sys/fs/cgroup/memory/docker"`
 }
 
 func (m *DockerContainersMemory) Run() error {
@@ -11,7 +11,7 @@ import (
 )
 
 func init() {
-	readers.Register("DockerContainersMemory", NewDockerContainersMemory)
+	readers.Register("DockerContainersMemory", NewDockerContainersMemory, "Memory usage of each Docker container, read from cgroups.")
 }
 
 func NewDockerContainersMemory() readers.IReader {
@@ -24,8 +24,8 @@ func NewDockerContainersMemory() readers.IReader {
 // Data source: https://github.com/shirou/gopsutil/blob/master/docker/docker_linux.go
 type DockerContainersMemory struct {
 	Data           map[string]*gopsutil_docker.CgroupMemStat
-	DockerHost     string
-	CgroupBasePath string
+	DockerHost     string `description:"Docker daemon address, DOCKER_HOST is used when empty." example:"unix:///var/run/docker.sock"`
+	CgroupBasePath string `description:"Cgroup directory of Docker containers." example:"/sys/fs/cgroup/memory/docker"`
 }
 
 // Run gathers cgroup memory information from cgroup itself.
@@ -8,7 +8,7 @@ import (
 )
 
 func init() {
-	readers.Register("DockerContainersNetDev", NewDockerContainersNetDev)
+	readers.Register("DockerContainersNetDev", NewDockerContainersNetDev, "Network devices statistics of each Docker container.")
 }
 
 func NewDockerContainersNetDev() readers.IReader {
@@ -19,8 +19,8 @@ func NewDockerContainersNetDev() readers.IReader {
 
 type DockerContainersNetDev struct {
 	Data           map[string]string
-	DockerHost     string
-	CgroupBasePath string
+	DockerHost     string `description:"Docker daemon address, DOCKER_HOST is used when empty." example:"unix:///var/run/docker.sock"`
+	CgroupBasePath string `description:"Cgroup directory of Docker containers." example:"/sys/fs/cgroup/memory/docker"`
 }
 
 func (m *DockerContainersNetDev) Run() error {
@@ -11,7 +11,7 @@ import (
 )
 
 func init() {
-	readers.Register("DockerContainersNetDev", NewDockerContainersNetDev)
+	readers.Register("DockerContainersNetDev", NewDockerContainersNetDev, "Network devices statistics of each Docker container.")
 }
 
 func NewDockerContainersNetDev() readers.IReader {
@@ -24,8 +24,8 @@ func NewDockerContainersNetDev() readers.IReader {
 // Data source: https://github.com/shirou/gopsutil/blob/master/docker/docker_linux.go
 type DockerContainersNetDev struct {
 	Data           map[string]map[string]linuxproc.NetworkStat
-	DockerHost     string
-	CgroupBasePath string
+	DockerHost     string `description:"Docker daemon address, DOCKER_HOST is used when empty." example:"unix:///var/run/docker.sock"`
+	CgroupBasePath string `description:"Cgroup directory of Docker containers." example:"/sys/fs/cgroup/memory/docker"`
 }
 
 // Run gathers cgroup memory information from cgroup itself.
@@ -7,7 +7,7 @@ import (
 )
 
 func init() {
-	readers.Register("DockerImages", NewDockerImages)
+	readers.Register("DockerImages", NewDockerImages, "Docker images.")
 }
 
 func NewDockerImages() readers.IReader {
@@ -19,7 +19,7 @@ func NewDockerImages() readers.IReader {
 // DockerImages gathers docker images data.
 type DockerImages struct {
 	Data       map[string]*libdocker.CompleteDockerImage
-	DockerHost string
+	DockerHost string `description:"Docker daemon address, DOCKER_HOST is used when empty." example:"unix:///var/run/docker.sock"`
 }
 
 func (di *DockerImages) Run() error {
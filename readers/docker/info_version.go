@@ -7,7 +7,7 @@ import (
 )
 
 func init() {
-	readers.Register("DockerInfoVersion", NewDockerInfoVersion)
+	readers.Register("DockerInfoVersion", NewDockerInfoVersion, "Docker daemon info and version.")
 }
 
 func NewDockerInfoVersion() readers.IReader {
@@ -17,7 +17,7 @@ func NewDockerInfoVersion() readers.IReader {
 // DockerInfoVersion gathers docker containers data.
 type DockerInfoVersion struct {
 	Data       map[string]interface{}
-	DockerHost string
+	DockerHost string `description:"Docker daemon address, DOCKER_HOST is used when empty." example:"unix:///var/run/docker.sock"`
 }
 
 // Run fetches info and version data.
@@ -12,7 +12,7 @@ import (
 )
 
 func init() {
-	Register("Du", NewDu)
+	Register("Du", NewDu, "Disk usage of paths, like du.")
 }
 
 func NewDu() IReader {
@@ -27,7 +27,7 @@ func NewDu() IReader {
 // * https://github.com/shirou/gopsutil/tree/master/disk
 type Du struct {
 	Data    map[string]map[string]interface{}
-	FSPaths string
+	FSPaths string `description:"Comma separated paths to report on top of every partition." example:"/var/log,/tmp"`
 }
 
 func (d *Du) buildData(path string) error {
@@ -6,7 +6,7 @@ import (
 )
 
 func init() {
-	Register("Free", NewFree)
+	Register("Free", NewFree, "Memory and swap usage, like free.")
 }
 
 func NewFree() IReader {
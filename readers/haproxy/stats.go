@@ -17,7 +17,7 @@ import (
 )
 
 func init() {
-	readers.Register("HAProxyStats", NewHAProxyStats)
+	readers.Register("HAProxyStats", NewHAProxyStats, "HAProxy statistics, fetched as CSV from Url.")
 }
 
 func NewHAProxyStats() readers.IReader {
@@ -94,7 +94,7 @@ func CSVtoJSON(csvInput string) ([]byte, error) {
 
 type HAProxyStats struct {
 	Data []map[string]interface{}
-	Url  string
+	Url  string `description:"CSV stats URL of HAProxy." example:"http://localhost:9000/haproxy_stats;csv"`
 }
 
 // Run executes the writer.
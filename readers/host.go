@@ -7,8 +7,8 @@ import (
 )
 
 func init() {
-	Register("HostInfo", NewHostInfo)
-	Register("HostUsers", NewHostUsers)
+	Register("HostInfo", NewHostInfo, "Hostname, OS, platform, and uptime of the host.")
+	Register("HostUsers", NewHostUsers, "Logged in users.")
 }
 
 func NewHostInfo() IReader {
@@ -7,7 +7,7 @@ import (
 )
 
 func init() {
-	Register("IOStat", NewIOStat)
+	Register("IOStat", NewIOStat, "Extended disk statistics from iostat.")
 }
 
 func NewIOStat() IReader {
@@ -6,7 +6,7 @@ import (
 )
 
 func init() {
-	Register("LoadAvg", NewLoadAvg)
+	Register("LoadAvg", NewLoadAvg, "Load averages of the last 1, 5, and 15 minutes.")
 }
 
 func NewLoadAvg() IReader {
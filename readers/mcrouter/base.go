@@ -4,6 +4,7 @@ package mcrouter
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -13,8 +14,24 @@ import (
 )
 
 type Base struct {
-	HostAndPort string
-	ConfigFile  string
+	HostAndPort string `description:"Address of mcrouter." example:"localhost:5000"`
+	ConfigFile  string `description:"Path to mcrouter JSON config file." example:"/etc/mcrouter/mcrouter.json"`
+
+	// ctx kills nc once it is done, see SetContext.
+	ctx context.Context
+}
+
+// SetContext kills nc once ctx is done, e.g. when mcrouter does not answer before the run of the reader timed out.
+func (mcr *Base) SetContext(ctx context.Context) {
+	mcr.ctx = ctx
+}
+
+// command constructs `*exec.Cmd` killed once ctx is done.
+func (mcr *Base) command(name string, args ...string) *exec.Cmd {
+	if mcr.ctx == nil {
+		return exec.Command(name, args...)
+	}
+	return exec.CommandContext(mcr.ctx, name, args...)
 }
 
 func (mcr *Base) Stats() (map[string]interface{}, error) {
@@ -45,8 +62,8 @@ func (mcr *Base) StatsFromNetcat() (map[string]interface{}, error) {
 		host = "localhost"
 	}
 
-	c1 := exec.Command("echo", "stats")
-	c2 := exec.Command("nc", host, port)
+	c1 := mcr.command("echo", "stats")
+	c2 := mcr.command("nc", host, port)
 
 	var c2Output bytes.Buffer
 
@@ -6,7 +6,7 @@ import (
 )
 
 func init() {
-	readers.Register("McRouterStats", NewMcRouterStats)
+	readers.Register("McRouterStats", NewMcRouterStats, "Stats of mcrouter at HostAndPort, plus its ConfigFile. Requires netcat.")
 }
 
 func NewMcRouterStats() readers.IReader {
@@ -4,13 +4,30 @@ package memcache
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"os/exec"
 	"strconv"
 	"strings"
 )
 
 type Base struct {
-	HostAndPort string
+	HostAndPort string `description:"Address of memcached." example:"localhost:11211"`
+
+	// ctx kills nc once it is done, see SetContext.
+	ctx context.Context
+}
+
+// SetContext kills nc once ctx is done, e.g. when memcached does not answer before the run of the reader timed out.
+func (mc *Base) SetContext(ctx context.Context) {
+	mc.ctx = ctx
+}
+
+// command constructs `*exec.Cmd` killed once ctx is done.
+func (mc *Base) command(name string, args ...string) *exec.Cmd {
+	if mc.ctx == nil {
+		return exec.Command(name, args...)
+	}
+	return exec.CommandContext(mc.ctx, name, args...)
 }
 
 func (mc *Base) Stats() (map[string]interface{}, error) {
@@ -22,8 +39,8 @@ func (mc *Base) Stats() (map[string]interface{}, error) {
 		host = "localhost"
 	}
 
-	c1 := exec.Command("echo", "stats")
-	c2 := exec.Command("nc", host, port)
+	c1 := mc.command("echo", "stats")
+	c2 := mc.command("nc", host, port)
 
 	var c2Output bytes.Buffer
 
@@ -7,7 +7,7 @@ import (
 )
 
 func init() {
-	readers.Register("MemcacheStats", NewMemcacheStats)
+	readers.Register("MemcacheStats", NewMemcacheStats, "Stats of memcached at HostAndPort.")
 }
 
 func NewMemcacheStats() readers.IReader {
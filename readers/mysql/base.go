@@ -13,8 +13,8 @@ var connections map[string]*sqlx.DB
 var connectionsLock = &sync.RWMutex{}
 
 type Base struct {
-	HostAndPort string
-	Retries     int
+	HostAndPort string `description:"Address of MySQL, connected to as root." example:"localhost:3306"`
+	Retries     int    `default:"10" description:"Connection attempts, with exponential backoff."`
 }
 
 func (m *Base) initConnection() error {
@@ -8,7 +8,7 @@ import (
 )
 
 func init() {
-	readers.Register("MysqlInformationSchemaTables", NewMysqlInformationSchemaTables)
+	readers.Register("MysqlInformationSchemaTables", NewMysqlInformationSchemaTables, "Size and row count of every MySQL table at HostAndPort.")
 }
 
 func NewMysqlInformationSchemaTables() readers.IReader {
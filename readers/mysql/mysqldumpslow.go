@@ -11,7 +11,7 @@ import (
 )
 
 func init() {
-	readers.Register("MysqlDumpSlow", NewMysqlDumpSlow)
+	readers.Register("MysqlDumpSlow", NewMysqlDumpSlow, "Summary of MySQL slow query log in FilePath, produced by mysqldumpslow.")
 }
 
 func NewMysqlDumpSlow() readers.IReader {
@@ -24,8 +24,8 @@ func NewMysqlDumpSlow() readers.IReader {
 // MysqlDumpSlow is a reader that parses mysqldumpslow output.
 type MysqlDumpSlow struct {
 	Data     map[string][]DumpSlow
-	Options  string
-	FilePath string
+	Options  string `required:"true" description:"Options of mysqldumpslow." example:"-t 10 -s at"`
+	FilePath string `required:"true" description:"Path to MySQL slow query log." example:"/var/log/mysql/mysql-slow.log"`
 }
 
 type DumpSlow struct {
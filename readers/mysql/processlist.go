@@ -10,7 +10,7 @@ import (
 )
 
 func init() {
-	readers.Register("MysqlProcesslist", NewMysqlProcesslist)
+	readers.Register("MysqlProcesslist", NewMysqlProcesslist, "Running MySQL queries from SHOW PROCESSLIST at HostAndPort.")
 }
 
 func NewMysqlProcesslist() readers.IReader {
@@ -3,13 +3,10 @@ package readers
 import (
 	"encoding/json"
 	"strings"
-
-	"github.com/resourced/resourced/libprocess"
-	"github.com/resourced/resourced/libstring"
 )
 
 func init() {
-	Register("NagiosPlugin", NewNagiosPlugin)
+	Register("NagiosPlugin", NewNagiosPlugin, "Runs a Nagios plugin in Command and reports its status and message.")
 }
 
 func NewNagiosPlugin() IReader {
@@ -25,15 +22,9 @@ type NagiosPlugin struct {
 
 // Run NagiosPlugins out external program and store the output on c.Data.
 func (s *NagiosPlugin) Run() error {
-	if s.Command != "" {
-		s.Command = libstring.ExpandTildeAndEnv(s.Command)
-
-		nagiosPluginOutputBytes, err := libprocess.NewCmd(s.Command).CombinedOutput()
-		if err != nil {
-			s.Data["ExitStatus"] = 1
-		} else {
-			s.Data["ExitStatus"] = 0
-		}
+	if s.Command != "" || len(s.Args) > 0 {
+		// Nagios plugins exit with their status: 0 OK, 1 WARNING, 2 CRITICAL, 3 UNKNOWN.
+		nagiosPluginOutputBytes := s.runCommand()
 
 		nagiosPluginOutput := strings.TrimSpace(string(nagiosPluginOutputBytes))
 
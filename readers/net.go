@@ -7,8 +7,8 @@ import (
 )
 
 func init() {
-	Register("NetIO", NewNetIO)
-	Register("NetInterfaces", NewNetInterfaces)
+	Register("NetIO", NewNetIO, "I/O counters of each network interface.")
+	Register("NetInterfaces", NewNetInterfaces, "Network interfaces and their addresses.")
 }
 
 func NewNetIO() IReader {
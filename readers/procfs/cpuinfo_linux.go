@@ -9,7 +9,7 @@ import (
 )
 
 func init() {
-	readers.Register("ProcCpuInfo", NewProcCpuInfo)
+	readers.Register("ProcCpuInfo", NewProcCpuInfo, "Content of /proc/cpuinfo.")
 }
 
 // NewProcCpuInfo is ProcCpuInfo constructor.
@@ -9,7 +9,7 @@ import (
 )
 
 func init() {
-	readers.Register("ProcDiskStats", NewProcDiskStats)
+	readers.Register("ProcDiskStats", NewProcDiskStats, "Content of /proc/diskstats.")
 }
 
 // NewProcDiskStats is ProcDiskStats constructor.
@@ -9,7 +9,7 @@ import (
 )
 
 func init() {
-	readers.Register("ProcLoadAvg", NewProcLoadAvg)
+	readers.Register("ProcLoadAvg", NewProcLoadAvg, "Content of /proc/loadavg.")
 }
 
 // NewProcLoadAvg is ProcLoadAvg constructor.
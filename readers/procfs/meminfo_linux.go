@@ -9,7 +9,7 @@ import (
 )
 
 func init() {
-	readers.Register("ProcMemInfo", NewProcMemInfo)
+	readers.Register("ProcMemInfo", NewProcMemInfo, "Content of /proc/meminfo.")
 }
 
 // NewProcMemInfo is ProcMemInfo constructor.
@@ -9,7 +9,7 @@ import (
 )
 
 func init() {
-	readers.Register("ProcMounts", NewProcMounts)
+	readers.Register("ProcMounts", NewProcMounts, "Content of /proc/mounts.")
 }
 
 // NewProcMounts is ProcMounts constructor.
@@ -9,7 +9,7 @@ import (
 )
 
 func init() {
-	readers.Register("ProcNetDev", NewProcNetDev)
+	readers.Register("ProcNetDev", NewProcNetDev, "Content of /proc/net/dev.")
 }
 
 // NewProcNetDev is ProcNetDev constructor.
@@ -9,7 +9,7 @@ import (
 )
 
 func init() {
-	readers.Register("ProcNetDevPid", NewProcNetDevPid)
+	readers.Register("ProcNetDevPid", NewProcNetDevPid, "Network devices statistics of each process, from /proc/{pid}/net/dev.")
 }
 
 // NewProcNetDevPid is ProcNetDevPid constructor.
@@ -11,7 +11,7 @@ import (
 )
 
 func init() {
-	readers.Register("ProcNetDevPid", NewProcNetDevPid)
+	readers.Register("ProcNetDevPid", NewProcNetDevPid, "Network devices statistics of each process, from /proc/{pid}/net/dev.")
 }
 
 // NewProcNetDevPid is ProcNetDevPid constructor.
@@ -9,7 +9,7 @@ import (
 )
 
 func init() {
-	readers.Register("ProcStat", NewProcStat)
+	readers.Register("ProcStat", NewProcStat, "Content of /proc/stat.")
 }
 
 // NewProcStat is ProcStat constructor.
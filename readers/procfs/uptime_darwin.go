@@ -9,7 +9,7 @@ import (
 )
 
 func init() {
-	readers.Register("ProcUptime", NewProcUptime)
+	readers.Register("ProcUptime", NewProcUptime, "Content of /proc/uptime.")
 }
 
 // NewProcUptime is ProcUptime constructor.
@@ -9,7 +9,7 @@ import (
 )
 
 func init() {
-	readers.Register("ProcVmStat", NewProcVmStat)
+	readers.Register("ProcVmStat", NewProcVmStat, "Content of /proc/vmstat.")
 }
 
 // NewProcVmStat is ProcVmStat constructor.
@@ -10,19 +10,19 @@ import (
 )
 
 func init() {
-	Register("Ps", NewPs)
+	Register("Ps", NewPs, "Running processes.")
 }
 
 func NewPs() IReader {
 	p := &Ps{}
 	p.Data = make(map[string]map[string]interface{})
-	p.NameFilter = make([]interface{}, 0)
+	p.NameFilter = make([]string, 0)
 	return p
 }
 
 type Ps struct {
 	Data       map[string]map[string]interface{}
-	NameFilter []interface{}
+	NameFilter []string `description:"Only processes whose name contains one of these substrings are reported." example:"nginx,redis-server"`
 }
 
 // Run gathers ps information from gosigar.
@@ -52,9 +52,7 @@ func (p *Ps) Run() error {
 		if len(p.NameFilter) > 0 {
 			matched := false
 
-			for _, nameFilterInterface := range p.NameFilter {
-				nameFilter := nameFilterInterface.(string)
-
+			for _, nameFilter := range p.NameFilter {
 				if strings.Contains(state.Name, nameFilter) {
 					matched = true
 					break
@@ -8,7 +8,7 @@ import (
 var connections map[string]redis.Conn
 
 type Base struct {
-	HostAndPort string
+	HostAndPort string `description:"Address of Redis." example:"localhost:6379"`
 }
 
 func (r *Base) initConnection() error {
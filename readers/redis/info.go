@@ -9,7 +9,7 @@ import (
 )
 
 func init() {
-	readers.Register("RedisInfo", NewRedisInfo)
+	readers.Register("RedisInfo", NewRedisInfo, "Output of Redis INFO command at HostAndPort.")
 }
 
 func NewRedisInfo() readers.IReader {
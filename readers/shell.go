@@ -2,13 +2,14 @@ package readers
 
 import (
 	"encoding/json"
+	"strings"
 
 	"github.com/resourced/resourced/libprocess"
 	"github.com/resourced/resourced/libstring"
 )
 
 func init() {
-	Register("Shell", NewShell)
+	Register("Shell", NewShell, "Runs Command and reports its JSON output.")
 }
 
 func NewShell() IReader {
@@ -19,27 +20,45 @@ func NewShell() IReader {
 }
 
 type Shell struct {
-	Command string
+	libprocess.Options
+	Command string `description:"Command printing JSON to standard output. Quote arguments like a shell does." example:"~/bin/memory.py --unit 'kB'"`
 	Data    map[string]interface{}
 }
 
+// runCommand runs Command, or Args, and stores its exit status and standard error on s.Data.
+// It returns standard output.
+func (s *Shell) runCommand() []byte {
+	s.Command = libstring.ExpandTildeAndEnv(s.Command)
+
+	result, err := s.Options.Run(s.Command, nil)
+	if result == nil {
+		s.Data["ExitStatus"] = 1
+		s.Data["Stderr"] = err.Error()
+		return nil
+	}
+
+	s.Data["ExitStatus"] = result.ExitStatus
+
+	stderr := strings.TrimSpace(string(result.Stderr))
+	if result.TimedOut {
+		stderr = strings.TrimSpace(stderr + "\n" + err.Error())
+	}
+	if stderr != "" {
+		s.Data["Stderr"] = stderr
+	}
+
+	return result.Stdout
+}
+
 // Run shells out external program and store the output on c.Data.
 func (s *Shell) Run() error {
-	if s.Command != "" {
-		s.Command = libstring.ExpandTildeAndEnv(s.Command)
-
-		outputJson, err := libprocess.NewCmd(s.Command).CombinedOutput()
+	if s.Command != "" || len(s.Args) > 0 {
+		outputJson := s.runCommand()
 
 		var output map[string]interface{}
 		json.Unmarshal(outputJson, &output)
 
 		s.Data["Output"] = output
-
-		if err != nil {
-			s.Data["ExitStatus"] = 1
-		} else {
-			s.Data["ExitStatus"] = 0
-		}
 	}
 
 	return nil
@@ -7,7 +7,7 @@ import (
 )
 
 func init() {
-	Register("Uptime", NewUptime)
+	Register("Uptime", NewUptime, "Uptime, current time, and load averages of the host.")
 }
 
 func NewUptime() IReader {
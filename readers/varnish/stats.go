@@ -8,7 +8,7 @@ import (
 )
 
 func init() {
-	readers.Register("VarnishStats", NewVarnishStats)
+	readers.Register("VarnishStats", NewVarnishStats, "Varnish statistics from varnishstat.")
 }
 
 func NewVarnishStats() readers.IReader {
@@ -0,0 +1,41 @@
+// Package storage provides backends for keeping readers/writers/executors results.
+package storage
+
+import (
+	"time"
+)
+
+// IStorage is generic interface for all result storage backends.
+// Every value is stored with the backend's TTL; expired values are never returned.
+type IStorage interface {
+	Set(key string, value []byte) error
+	Get(key string) ([]byte, bool)
+	Items() map[string][]byte
+	Delete(key string) error
+	Close() error
+}
+
+// New returns the on-disk storage when dbPath is defined, otherwise it returns in-memory storage.
+func New(dbPath string, ttl time.Duration) (IStorage, error) {
+	if dbPath == "" {
+		return NewMemory(ttl), nil
+	}
+	return NewDisk(dbPath, ttl)
+}
+
+// expirationFromTTL returns the expiration timestamp in UnixNano for a value set now.
+// Non-positive ttl means the value never expires, denoted by 0.
+func expirationFromTTL(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return time.Now().Add(ttl).UnixNano()
+}
+
+// isExpired checks if expiration timestamp in UnixNano has passed.
+func isExpired(expiration int64) bool {
+	if expiration == 0 {
+		return false
+	}
+	return time.Now().UnixNano() > expiration
+}
@@ -0,0 +1,272 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/resourced/resourced/libstring"
+)
+
+// DiskFilename is the name of the file created under dbPath by Disk storage.
+const DiskFilename = "results.db"
+
+// diskCompactionMinRecords is the minimum number of appended records before Disk storage compacts its file.
+const diskCompactionMinRecords = 1000
+
+// NewDisk is the constructor for Disk.
+// It creates dbPath directory if needed and loads every non-expired value from the previous run.
+func NewDisk(dbPath string, ttl time.Duration) (*Disk, error) {
+	dbPath = libstring.ExpandTildeAndEnv(dbPath)
+
+	err := os.MkdirAll(dbPath, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Disk{}
+	d.path = filepath.Join(dbPath, DiskFilename)
+	d.ttl = ttl
+	d.data = make(map[string]diskRecord)
+
+	err = d.load()
+	if err != nil {
+		return nil, err
+	}
+
+	// Start with a compacted file, this also drops records that expired while the agent was down.
+	err = d.compact()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// diskRecord is a single line in the append-only file.
+type diskRecord struct {
+	Key        string
+	Value      []byte
+	Expiration int64
+	Deleted    bool `json:",omitempty"`
+}
+
+// Disk keeps results in an append-only file so they survive restarts.
+// Every change is appended as one JSON line, the file is rewritten with only live records once it grows too large.
+type Disk struct {
+	path     string
+	ttl      time.Duration
+	data     map[string]diskRecord
+	file     *os.File
+	appended int
+	sync.RWMutex
+}
+
+// load replays the file into memory. Malformed lines, e.g. a partial write during a crash, are skipped.
+func (d *Disk) load() error {
+	file, err := os.Open(d.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			var record diskRecord
+			if json.Unmarshal(line, &record) == nil {
+				if record.Deleted {
+					delete(d.data, record.Key)
+				} else {
+					d.data[record.Key] = record
+				}
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// compact rewrites the file with non-expired records only and reopens it for appending.
+// The caller must hold the lock, or be the constructor.
+func (d *Disk) compact() error {
+	tmpPath := d.path + ".tmp"
+
+	tmpFile, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(tmpFile)
+
+	for key, record := range d.data {
+		if isExpired(record.Expiration) {
+			delete(d.data, key)
+			continue
+		}
+
+		err = writeDiskRecord(writer, record)
+		if err != nil {
+			tmpFile.Close()
+			return err
+		}
+	}
+
+	err = writer.Flush()
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	err = tmpFile.Close()
+	if err != nil {
+		return err
+	}
+
+	if d.file != nil {
+		d.file.Close()
+		d.file = nil
+	}
+
+	err = os.Rename(tmpPath, d.path)
+	if err != nil {
+		return err
+	}
+
+	d.file, err = os.OpenFile(d.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	d.appended = len(d.data)
+
+	return nil
+}
+
+// writeDiskRecord serializes record as one JSON line.
+func writeDiskRecord(w io.Writer, record diskRecord) error {
+	recordJson, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(recordJson, '\n'))
+	return err
+}
+
+// append writes record to the file and compacts the file when it has grown too large.
+// The caller must hold the lock.
+func (d *Disk) append(record diskRecord) error {
+	if d.file == nil {
+		return errors.New("Disk storage is closed.")
+	}
+
+	err := writeDiskRecord(d.file, record)
+	if err != nil {
+		return err
+	}
+
+	d.appended++
+
+	if d.appended > diskCompactionMinRecords && d.appended > 2*len(d.data) {
+		return d.compact()
+	}
+
+	return nil
+}
+
+// Set value.
+func (d *Disk) Set(key string, value []byte) error {
+	record := diskRecord{
+		Key:        key,
+		Value:      value,
+		Expiration: expirationFromTTL(d.ttl),
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	d.data[key] = record
+
+	return d.append(record)
+}
+
+// Get value.
+func (d *Disk) Get(key string) ([]byte, bool) {
+	d.RLock()
+	defer d.RUnlock()
+
+	record, found := d.data[key]
+	if !found || isExpired(record.Expiration) {
+		return nil, false
+	}
+
+	return record.Value, true
+}
+
+// Items returns all non-expired values.
+func (d *Disk) Items() map[string][]byte {
+	d.RLock()
+	defer d.RUnlock()
+
+	items := make(map[string][]byte)
+
+	for key, record := range d.data {
+		if !isExpired(record.Expiration) {
+			items[key] = record.Value
+		}
+	}
+
+	return items
+}
+
+// Delete by key.
+func (d *Disk) Delete(key string) error {
+	d.Lock()
+	defer d.Unlock()
+
+	if _, found := d.data[key]; !found {
+		return nil
+	}
+
+	delete(d.data, key)
+
+	return d.append(diskRecord{Key: key, Deleted: true})
+}
+
+// Close flushes the file to disk and closes it.
+func (d *Disk) Close() error {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.file == nil {
+		return nil
+	}
+
+	err := d.file.Sync()
+	closeErr := d.file.Close()
+	d.file = nil
+
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
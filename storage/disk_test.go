@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func newDiskForTest(t *testing.T, dbPath string, ttl time.Duration) *Disk {
+	d, err := NewDisk(dbPath, ttl)
+	if err != nil {
+		t.Fatalf("Creating disk storage should work. Error: %v", err)
+	}
+	return d
+}
+
+func TestDiskSurvivesReopen(t *testing.T) {
+	dbPath, err := ioutil.TempDir("", "resourced-storage")
+	if err != nil {
+		t.Fatalf("Creating temp dir should work. Error: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+
+	d := newDiskForTest(t, dbPath, time.Minute)
+
+	d.Set("/r/load-avg", []byte(`{"Data": {"LoadAvg1m": 0.904296875}}`))
+	d.Set("/r/uptime", []byte(`{"Data": {}}`))
+	d.Set("/r/uptime", []byte(`{"Data": {"Uptime": 1}}`))
+	d.Set("/r/free", []byte(`{}`))
+	d.Delete("/r/free")
+
+	err = d.Close()
+	if err != nil {
+		t.Fatalf("Closing disk storage should work. Error: %v", err)
+	}
+
+	d = newDiskForTest(t, dbPath, time.Minute)
+	defer d.Close()
+
+	value, found := d.Get("/r/load-avg")
+	if !found || string(value) != `{"Data": {"LoadAvg1m": 0.904296875}}` {
+		t.Errorf("Value should survive reopen. Value: %s", value)
+	}
+
+	value, found = d.Get("/r/uptime")
+	if !found || string(value) != `{"Data": {"Uptime": 1}}` {
+		t.Errorf("Latest value should survive reopen. Value: %s", value)
+	}
+
+	if _, found := d.Get("/r/free"); found {
+		t.Errorf("Deleted value should not come back after reopen.")
+	}
+}
+
+func TestDiskTTL(t *testing.T) {
+	dbPath, err := ioutil.TempDir("", "resourced-storage")
+	if err != nil {
+		t.Fatalf("Creating temp dir should work. Error: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+
+	d := newDiskForTest(t, dbPath, time.Millisecond)
+
+	d.Set("/r/load-avg", []byte(`{}`))
+	d.Close()
+
+	time.Sleep(5 * time.Millisecond)
+
+	d = newDiskForTest(t, dbPath, time.Millisecond)
+	defer d.Close()
+
+	if _, found := d.Get("/r/load-avg"); found {
+		t.Errorf("Value should have expired.")
+	}
+	if len(d.Items()) != 0 {
+		t.Errorf("Expired value should not be returned. Items: %v", d.Items())
+	}
+}
+
+func TestDiskCompaction(t *testing.T) {
+	dbPath, err := ioutil.TempDir("", "resourced-storage")
+	if err != nil {
+		t.Fatalf("Creating temp dir should work. Error: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+
+	d := newDiskForTest(t, dbPath, time.Minute)
+	defer d.Close()
+
+	for i := 0; i < 3*diskCompactionMinRecords; i++ {
+		d.Set("/r/load-avg", []byte(`{}`))
+	}
+
+	if d.appended > diskCompactionMinRecords+1 {
+		t.Errorf("File should have been compacted. Appended records: %v", d.appended)
+	}
+
+	if _, found := d.Get("/r/load-avg"); !found {
+		t.Errorf("Value should survive compaction.")
+	}
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"sync"
+	"time"
+)
+
+// NewHistory is the constructor for History.
+// count is the maximum number of samples kept per key, retention is the maximum age of a sample.
+// Non-positive retention keeps samples until they are pushed out by newer ones.
+func NewHistory(count int, retention time.Duration) *History {
+	h := &History{}
+	h.count = count
+	h.retention = retention
+	h.rings = make(map[string]*ring)
+
+	return h
+}
+
+// Sample is a value recorded at a point in time.
+type Sample struct {
+	UnixNano int64
+	Value    []byte
+}
+
+// History keeps the last N samples per key in bounded ring buffers.
+type History struct {
+	count     int
+	retention time.Duration
+	rings     map[string]*ring
+	sync.RWMutex
+}
+
+// ring is a fixed capacity circular buffer of samples, ordered from oldest to newest.
+type ring struct {
+	samples []Sample
+	start   int
+	size    int
+}
+
+func (r *ring) add(sample Sample) {
+	if r.size < len(r.samples) {
+		r.samples[(r.start+r.size)%len(r.samples)] = sample
+		r.size++
+		return
+	}
+
+	r.samples[r.start] = sample
+	r.start = (r.start + 1) % len(r.samples)
+}
+
+func (r *ring) at(i int) Sample {
+	return r.samples[(r.start+i)%len(r.samples)]
+}
+
+// Add records value for key at unixNano.
+func (h *History) Add(key string, unixNano int64, value []byte) {
+	if h.count <= 0 {
+		return
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	r, ok := h.rings[key]
+	if !ok {
+		r = &ring{samples: make([]Sample, h.count)}
+		h.rings[key] = r
+	}
+
+	r.add(Sample{UnixNano: unixNano, Value: value})
+}
+
+// Range returns samples of key between from and to (inclusive), ordered from oldest to newest.
+// Zero from or to means unbounded. When limit is positive, only the newest limit samples are returned.
+// Samples older than the retention are never returned.
+func (h *History) Range(key string, from, to time.Time, limit int) []Sample {
+	h.RLock()
+	defer h.RUnlock()
+
+	samples := make([]Sample, 0)
+
+	r, ok := h.rings[key]
+	if !ok {
+		return samples
+	}
+
+	var fromNano, toNano int64
+
+	if !from.IsZero() {
+		fromNano = from.UnixNano()
+	}
+	if h.retention > 0 {
+		retentionNano := time.Now().Add(-h.retention).UnixNano()
+		if retentionNano > fromNano {
+			fromNano = retentionNano
+		}
+	}
+	if !to.IsZero() {
+		toNano = to.UnixNano()
+	}
+
+	for i := 0; i < r.size; i++ {
+		sample := r.at(i)
+
+		if fromNano != 0 && sample.UnixNano < fromNano {
+			continue
+		}
+		if toNano != 0 && sample.UnixNano > toNano {
+			continue
+		}
+
+		samples = append(samples, sample)
+	}
+
+	if limit > 0 && len(samples) > limit {
+		samples = samples[len(samples)-limit:]
+	}
+
+	return samples
+}
+
+// Retention returns the maximum age of a sample, zero when samples are only pushed out by newer ones.
+func (h *History) Retention() time.Duration {
+	return h.retention
+}
+
+// Delete removes all samples of key.
+func (h *History) Delete(key string) {
+	h.Lock()
+	defer h.Unlock()
+
+	delete(h.rings, key)
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestHistoryKeepsLastNSamples(t *testing.T) {
+	h := NewHistory(3, 0)
+
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		h.Add("/r/load-avg", now.Add(time.Duration(i)*time.Second).UnixNano(), []byte(fmt.Sprintf("%v", i)))
+	}
+
+	samples := h.Range("/r/load-avg", time.Time{}, time.Time{}, 0)
+	if len(samples) != 3 {
+		t.Fatalf("History should keep only 3 samples. Samples: %v", samples)
+	}
+
+	for i, expected := range []string{"2", "3", "4"} {
+		if string(samples[i].Value) != expected {
+			t.Errorf("Samples should be ordered from oldest to newest. Index: %v, Value: %s", i, samples[i].Value)
+		}
+	}
+}
+
+func TestHistoryRangeAndLimit(t *testing.T) {
+	h := NewHistory(10, 0)
+
+	now := time.Now()
+
+	for i := 0; i < 10; i++ {
+		h.Add("/r/load-avg", now.Add(time.Duration(i)*time.Minute).UnixNano(), []byte(fmt.Sprintf("%v", i)))
+	}
+
+	samples := h.Range("/r/load-avg", now.Add(2*time.Minute), now.Add(5*time.Minute), 0)
+	if len(samples) != 4 {
+		t.Fatalf("Range should include both ends. Samples: %v", samples)
+	}
+	if string(samples[0].Value) != "2" || string(samples[3].Value) != "5" {
+		t.Errorf("Range returned the wrong samples. Samples: %v", samples)
+	}
+
+	samples = h.Range("/r/load-avg", time.Time{}, time.Time{}, 2)
+	if len(samples) != 2 || string(samples[1].Value) != "9" {
+		t.Errorf("Limit should return the newest samples. Samples: %v", samples)
+	}
+
+	if len(h.Range("/r/does-not-exist", time.Time{}, time.Time{}, 0)) != 0 {
+		t.Errorf("Unknown key should not have samples.")
+	}
+}
+
+func TestHistoryRetention(t *testing.T) {
+	h := NewHistory(10, time.Minute)
+
+	now := time.Now()
+
+	h.Add("/r/load-avg", now.Add(-2*time.Minute).UnixNano(), []byte("old"))
+	h.Add("/r/load-avg", now.UnixNano(), []byte("new"))
+
+	samples := h.Range("/r/load-avg", time.Time{}, time.Time{}, 0)
+	if len(samples) != 1 || string(samples[0].Value) != "new" {
+		t.Errorf("Samples older than retention should not be returned. Samples: %v", samples)
+	}
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"time"
+
+	gocache "github.com/patrickmn/go-cache"
+)
+
+// NewMemory is the constructor for Memory.
+func NewMemory(ttl time.Duration) *Memory {
+	if ttl <= 0 {
+		ttl = gocache.NoExpiration
+	}
+
+	m := &Memory{}
+	m.cache = gocache.New(ttl, 10*time.Second)
+
+	return m
+}
+
+// Memory keeps results in-memory. Results are gone after restart.
+type Memory struct {
+	cache *gocache.Cache
+}
+
+// Set value.
+func (m *Memory) Set(key string, value []byte) error {
+	m.cache.Set(key, value, gocache.DefaultExpiration)
+	return nil
+}
+
+// Get value.
+func (m *Memory) Get(key string) ([]byte, bool) {
+	valueInterface, found := m.cache.Get(key)
+	if !found {
+		return nil, false
+	}
+	return valueInterface.([]byte), true
+}
+
+// Items returns all non-expired values.
+func (m *Memory) Items() map[string][]byte {
+	items := make(map[string][]byte)
+
+	for key, item := range m.cache.Items() {
+		if !item.Expired() {
+			items[key] = item.Object.([]byte)
+		}
+	}
+
+	return items
+}
+
+// Delete by key.
+func (m *Memory) Delete(key string) error {
+	m.cache.Delete(key)
+	return nil
+}
+
+// Close does nothing, in-memory storage has nothing to release.
+func (m *Memory) Close() error {
+	return nil
+}
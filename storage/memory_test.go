@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemorySetGet(t *testing.T) {
+	m := NewMemory(time.Minute)
+
+	err := m.Set("/r/load-avg", []byte(`{"Data": {"LoadAvg1m": 0.904296875}}`))
+	if err != nil {
+		t.Fatalf("Set should work. Error: %v", err)
+	}
+
+	value, found := m.Get("/r/load-avg")
+	if !found {
+		t.Fatalf("Value should be found.")
+	}
+	if string(value) != `{"Data": {"LoadAvg1m": 0.904296875}}` {
+		t.Errorf("Value is incorrect. Value: %s", value)
+	}
+
+	if len(m.Items()) != 1 {
+		t.Errorf("There should be 1 item. Items: %v", m.Items())
+	}
+
+	m.Delete("/r/load-avg")
+
+	if _, found := m.Get("/r/load-avg"); found {
+		t.Errorf("Value should be deleted.")
+	}
+}
+
+func TestMemoryTTL(t *testing.T) {
+	m := NewMemory(time.Millisecond)
+
+	m.Set("/r/load-avg", []byte(`{}`))
+	time.Sleep(5 * time.Millisecond)
+
+	if _, found := m.Get("/r/load-avg"); found {
+		t.Errorf("Value should have expired.")
+	}
+	if len(m.Items()) != 0 {
+		t.Errorf("Expired value should not be returned. Items: %v", m.Items())
+	}
+}
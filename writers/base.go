@@ -3,9 +3,13 @@ package writers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
-	"reflect"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/go-fsnotify/fsnotify"
@@ -15,9 +19,11 @@ import (
 )
 
 var writerConstructors = make(map[string]func() IWriter)
+var writerDescriptions = make(map[string]string)
 
 // Register makes any writer constructor available by name.
-func Register(name string, constructor func() IWriter) {
+// description tells what the writer does, it is shown by GET /gostructs and resourced list-gostructs.
+func Register(name string, constructor func() IWriter, description string) {
 	if constructor == nil {
 		panic("writer: Register writer constructor is nil")
 	}
@@ -25,6 +31,12 @@ func Register(name string, constructor func() IWriter) {
 		panic("writer: Register called twice for writer constructor " + name)
 	}
 	writerConstructors[name] = constructor
+	writerDescriptions[name] = description
+}
+
+// Description returns the description of a registered writer constructor.
+func Description(name string) string {
+	return writerDescriptions[name]
 }
 
 // NewGoStruct instantiates IWriter
@@ -37,6 +49,16 @@ func NewGoStruct(name string) (IWriter, error) {
 	return constructor(), nil
 }
 
+// Names returns names of all registered writer constructors, sorted.
+func Names() []string {
+	names := make([]string, 0, len(writerConstructors))
+	for name := range writerConstructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // NewGoStructByConfig instantiates IWriter given Config struct
 func NewGoStructByConfig(config resourced_config.Config) (IWriter, error) {
 	writer, err := NewGoStruct(config.GoStruct)
@@ -44,16 +66,10 @@ func NewGoStructByConfig(config resourced_config.Config) (IWriter, error) {
 		return nil, err
 	}
 
-	// Populate IWriter fields dynamically
-	if len(config.GoStructFields) > 0 {
-		for structFieldInString, value := range config.GoStructFields {
-			goStructField := reflect.ValueOf(writer).Elem().FieldByName(structFieldInString)
-
-			if goStructField.IsValid() && goStructField.CanSet() {
-				valueOfValue := reflect.ValueOf(value)
-				goStructField.Set(valueOfValue)
-			}
-		}
+	// Populate IWriter fields, converting values to the types of its fields
+	err = resourced_config.DecodeGoStructFields(config.GoStructFields, writer)
+	if err != nil {
+		return nil, err
 	}
 
 	return writer, err
@@ -78,7 +94,18 @@ type Base struct {
 	Configs       *resourced_config.Configs
 	ReadersData   map[string]interface{}
 	Data          interface{}
-	JsonProcessor string
+	JsonProcessor string `description:"Program that receives readers data as JSON on standard input and prints the JSON to write." example:"~/bin/json-flattener.py"`
+
+	// JsonProcessorTimeout kills JsonProcessor and its children after this duration.
+	JsonProcessorTimeout time.Duration `default:"30s" description:"Kill JsonProcessor and its children after this duration."`
+
+	// ctx kills JsonProcessor and its children once it is done, see SetContext.
+	ctx context.Context
+}
+
+// SetContext kills JsonProcessor and its children once ctx is done, e.g. when the run of the writer timed out.
+func (b *Base) SetContext(ctx context.Context) {
+	b.ctx = ctx
 }
 
 // WatchDir watches a directory and execute callback on any changes.
@@ -184,8 +211,6 @@ func (b *Base) GenerateData() error {
 
 	} else {
 		// If there is a JsonProcessor
-		cmd := libprocess.NewCmd(processorPath)
-
 		readersData := b.GetReadersData()
 
 		readersDataJsonBytes, err := json.Marshal(readersData)
@@ -193,15 +218,21 @@ func (b *Base) GenerateData() error {
 			return err
 		}
 
-		cmd.Stdin = bytes.NewReader(readersDataJsonBytes)
+		options := libprocess.Options{Timeout: b.JsonProcessorTimeout}
+		if b.ctx != nil {
+			options.SetContext(b.ctx)
+		}
 
-		postProcessingDataBytes, err := cmd.Output()
+		result, err := options.Run(processorPath, bytes.NewReader(readersDataJsonBytes))
 		if err != nil {
-			return err
+			if result != nil && len(result.Stderr) > 0 {
+				return fmt.Errorf("JsonProcessor failed. Error: %v. Stderr: %v", err, strings.TrimSpace(string(result.Stderr)))
+			}
+			return fmt.Errorf("JsonProcessor failed. Error: %v", err)
 		}
 
 		var postProcessingData interface{}
-		err = json.Unmarshal(postProcessingDataBytes, &postProcessingData)
+		err = json.Unmarshal(result.Stdout, &postProcessingData)
 		if err != nil {
 			return err
 		}
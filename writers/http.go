@@ -10,7 +10,7 @@ import (
 )
 
 func init() {
-	Register("Http", NewHttp)
+	Register("Http", NewHttp, "Sends readers data as JSON to Url.")
 }
 
 // NewHttp is Http constructor.
@@ -21,11 +21,11 @@ func NewHttp() IWriter {
 // Http is a writer that simply serialize all readers data to Http.
 type Http struct {
 	Base
-	Url      string
-	Method   string
-	Headers  string
-	Username string
-	Password string
+	Url      string `description:"Destination URL." example:"https://example.com/metrics"`
+	Method   string `default:"POST" description:"HTTP method."`
+	Headers  string `description:"Comma separated key=value HTTP headers." example:"X-Token=abc123,X-Teapot-Count=2"`
+	Username string `description:"Basic auth username."`
+	Password string `description:"Basic auth password."`
 }
 
 // headersAsMap parses the headers data as string and returns them as map.
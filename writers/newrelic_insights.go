@@ -9,7 +9,7 @@ import (
 )
 
 func init() {
-	Register("NewrelicInsights", NewNewrelicInsights)
+	Register("NewrelicInsights", NewNewrelicInsights, "Sends readers data to New Relic Insights as events of EventType.")
 }
 
 // NewNewrelicInsights is NewrelicInsights constructor.
@@ -20,7 +20,7 @@ func NewNewrelicInsights() IWriter {
 // NewrelicInsights is a writer that serialize readers data to New Relic Insights.
 type NewrelicInsights struct {
 	Http
-	EventType string
+	EventType string `required:"true" description:"eventType of every Insights event." example:"ServerDiskUsage"`
 }
 
 func (nr *NewrelicInsights) reformatDataBeforeToJson(data interface{}) interface{} {
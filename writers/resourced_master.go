@@ -1,7 +1,7 @@
 package writers
 
 func init() {
-	Register("ResourcedMasterHost", NewResourcedMasterHost)
+	Register("ResourcedMasterHost", NewResourcedMasterHost, "Sends readers data of this host to ResourceD Master.")
 }
 
 // NewResourcedMasterHost is ResourcedMasterHost constructor.
@@ -2,14 +2,16 @@ package writers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"strings"
 
 	"github.com/resourced/resourced/libprocess"
 	"github.com/resourced/resourced/libstring"
 )
 
 func init() {
-	Register("Shell", NewShell)
+	Register("Shell", NewShell, "Pipes readers data as JSON into the standard input of Command.")
 }
 
 func NewShell() IWriter {
@@ -21,13 +23,20 @@ func NewShell() IWriter {
 
 type Shell struct {
 	Base
-	Command string
+	libprocess.Options
+	Command string `description:"Command receiving readers data as JSON on standard input." example:"~/bin/stdin-stdout.py"`
 	Data    map[string]interface{}
 }
 
+// SetContext kills JsonProcessor, Command, and their children once ctx is done.
+func (s *Shell) SetContext(ctx context.Context) {
+	s.Base.SetContext(ctx)
+	s.Options.SetContext(ctx)
+}
+
 // Run shells out external program and store the output on c.Data.
 func (s *Shell) Run() error {
-	if s.Command != "" {
+	if s.Command != "" || len(s.Args) > 0 {
 		s.Command = libstring.ExpandTildeAndEnv(s.Command)
 
 		readersDataJsonBytes, err := json.Marshal(s.GetReadersData())
@@ -35,20 +44,25 @@ func (s *Shell) Run() error {
 			return err
 		}
 
-		cmd := libprocess.NewCmd(s.Command)
-		cmd.Stdin = bytes.NewReader(readersDataJsonBytes)
-
-		outputJson, err := cmd.CombinedOutput()
+		result, err := s.Options.Run(s.Command, bytes.NewReader(readersDataJsonBytes))
+		if result == nil {
+			s.Data["ExitStatus"] = 1
+			s.Data["Stderr"] = err.Error()
+			return nil
+		}
 
 		var output map[string]interface{}
-		json.Unmarshal(outputJson, &output)
+		json.Unmarshal(result.Stdout, &output)
 
 		s.Data["Output"] = output
+		s.Data["ExitStatus"] = result.ExitStatus
 
-		if err != nil {
-			s.Data["ExitStatus"] = 1
-		} else {
-			s.Data["ExitStatus"] = 0
+		stderr := strings.TrimSpace(string(result.Stderr))
+		if result.TimedOut {
+			stderr = strings.TrimSpace(stderr + "\n" + err.Error())
+		}
+		if stderr != "" {
+			s.Data["Stderr"] = stderr
 		}
 	}
 
@@ -6,7 +6,7 @@ import (
 )
 
 func init() {
-	Register("StdOut", NewStdOut)
+	Register("StdOut", NewStdOut, "Prints readers data to standard output.")
 }
 
 // NewStdOut is StdOut constructor.